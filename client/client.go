@@ -246,12 +246,8 @@ func (cli *Client) SubmitBatchStatuses(wait int32, ids ...string) (*BatchStatuse
 	return ret, nil
 }
 
-// States return address states
-func (cli *Client) States(head, address, start string, limit int, reverse string) (*EntriesResp, error) {
-	q := cli.dataQS(head, start, limit, reverse)
-
-	url := fmt.Sprintf("%s/state%s", cli.endpoint, q)
-
+// StatesWithURL get states with url
+func (cli *Client) StatesWithURL(url string) (*EntriesResp, error) {
 	resp := cli.get(url)
 
 	ret := new(EntriesResp)
@@ -262,6 +258,19 @@ func (cli *Client) States(head, address, start string, limit int, reverse string
 	return ret, nil
 }
 
+// States return address states
+// address can be a full address or a prefix.
+func (cli *Client) States(head, address, start string, limit int, reverse string) (*EntriesResp, error) {
+	q := cli.dataQS(head, start, limit, reverse)
+	if address != "" {
+		q += "&address=" + address
+	}
+
+	url := fmt.Sprintf("%s/state%s", cli.endpoint, q)
+
+	return cli.StatesWithURL(url)
+}
+
 // State get state of an address
 func (cli *Client) State(address, head string) (*EntryResp, error) {
 	if address == "" {
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dairaga/sawtk/dump"
+)
+
+// runDump decodes file or stdin and prints in JSON or YAML.
+func runDump(args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	kind := fs.String("type", dump.KindAuto, "data type: auto, batchlist, batch, block or json")
+	format := fs.String("format", "json", "output format: json or yaml")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: sawtk dump [-type type] [-format format] [file]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var raw []byte
+	var err error
+	if fs.NArg() > 0 {
+		raw, err = ioutil.ReadFile(fs.Arg(0))
+	} else {
+		raw, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := dump.Decode(raw, *kind)
+	if err != nil {
+		return err
+	}
+
+	var out []byte
+	switch *format {
+	case "json":
+		out, err = dump.JSON(data)
+	case "yaml":
+		out, err = dump.YAML(data)
+	default:
+		err = fmt.Errorf("unknown format: %s", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(os.Stdout, string(out))
+	return err
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dairaga/sawtk/gen"
+)
+
+// runGen generates typed family code from a family definition.
+//
+// It is for go generate, ex: //go:generate sawtk gen family.yaml
+func runGen(args []string) error {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	out := fs.String("o", "", "output directory (default: directory of definition)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: sawtk gen [-o dir] <family.yaml>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("need a family definition")
+	}
+
+	d, err := gen.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	files, err := gen.Generate(d)
+	if err != nil {
+		return err
+	}
+
+	dir := *out
+	if dir == "" {
+		dir = filepath.Dir(fs.Arg(0))
+	}
+
+	written, err := gen.Write(dir, files)
+	for _, x := range written {
+		fmt.Println(x)
+	}
+	return err
+}
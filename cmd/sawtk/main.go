@@ -0,0 +1,55 @@
+// Command sawtk is a command line toolkit for Hyperledger Sawtooth.
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command is a sawtk subcommand.
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]*command{
+	"dump":       {"dump batch lists, batches, blocks or restful api responses", runDump},
+	"gen":        {"generate typed family code from a family definition", runGen},
+	"migrations": {"report records in state needing schema migration", runMigrations},
+	"scaffold":   {"generate a new family project", runScaffold},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: sawtk <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+
+	names := make([]string, 0, len(commands))
+	for k := range commands {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].usage)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "sawtk %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dairaga/sawtk/client"
+	"github.com/dairaga/sawtk/tp"
+)
+
+// runMigrations prints records under prefix older than schema version.
+func runMigrations(args []string) error {
+	fs := flag.NewFlagSet("migrations", flag.ExitOnError)
+	url := fs.String("url", "http://localhost:8008", "sawtooth restful api endpoint")
+	prefix := fs.String("prefix", "", "address prefix of records")
+	typ := fs.String("type", "", "protobuf message name of records")
+	version := fs.Uint("version", 1, "current schema version")
+	timeout := fs.Duration("timeout", 30*time.Second, "http timeout")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: sawtk migrations -prefix prefix -type type -version version [-url url]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *prefix == "" || *typ == "" {
+		return errors.New("prefix and type are required")
+	}
+
+	infos, err := tp.ScanMigrations(client.New(*url, *timeout), *prefix, tp.NewSchemaName(*typ, uint32(*version)))
+	if err != nil {
+		return err
+	}
+
+	for _, x := range infos {
+		fmt.Println(x.String())
+	}
+	fmt.Fprintf(os.Stderr, "%d records need migration\n", len(infos))
+	return nil
+}
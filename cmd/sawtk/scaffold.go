@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dairaga/sawtk/gen"
+)
+
+// runScaffold generates a new family project.
+//
+// ex: sawtk scaffold -m github.com/acme/wallet wallet
+func runScaffold(args []string) error {
+	fs := flag.NewFlagSet("scaffold", flag.ExitOnError)
+	module := fs.String("m", "", "module path of project (default: family)")
+	namespace := fs.String("ns", "", "namespace name (default: family)")
+	version := fs.String("v", "1.0", "family version")
+	out := fs.String("o", "", "project directory (default: family)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: sawtk scaffold [-m module] [-ns namespace] [-v version] [-o dir] <family>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("need a family name")
+	}
+
+	p := gen.Project{
+		Module:    *module,
+		Family:    fs.Arg(0),
+		Namespace: *namespace,
+		Version:   *version,
+	}
+	if p.Module == "" {
+		p.Module = p.Family
+	}
+
+	files, err := gen.Scaffold(p)
+	if err != nil {
+		return err
+	}
+
+	dir := *out
+	if dir == "" {
+		dir = p.Family
+	}
+
+	written, err := gen.Write(dir, files)
+	for _, x := range written {
+		fmt.Println(x)
+	}
+	return err
+}
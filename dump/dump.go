@@ -0,0 +1,295 @@
+/*
+Package dump renders sawtooth batch lists, batches, blocks and restful api
+responses in human-readable JSON or YAML.
+
+Headers are decoded, signers are annotated with wallet addresses, and payloads
+are decoded with protobuf types registered by Register and RegisterPayload.
+*/
+package dump
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+
+	"github.com/dairaga/sawtk/tp"
+	"github.com/dairaga/sawtk/wallet"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/batch_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/block_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+var marshaler = &jsonpb.Marshaler{OrigName: true}
+
+// walletOf returns wallet address of public key or empty string if public key is invalid.
+func walletOf(pubkey string) string {
+	w, err := wallet.MakeFromHex(pubkey)
+	if err != nil {
+		return ""
+	}
+	return w
+}
+
+// toGeneric converts protobuf message to generic map for JSON and YAML rendering.
+func toGeneric(pb proto.Message) (interface{}, error) {
+	buf := new(bytes.Buffer)
+	if err := marshaler.Marshal(buf, pb); err != nil {
+		return nil, err
+	}
+
+	var ret interface{}
+	if err := json.Unmarshal(buf.Bytes(), &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// Payload is a decoded transaction payload.
+type Payload struct {
+	Size     int         `json:"size" yaml:"size"`
+	Cmd      *int32      `json:"cmd,omitempty" yaml:"cmd,omitempty"`
+	Encoding string      `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+	Type     string      `json:"type,omitempty" yaml:"type,omitempty"`
+	Data     interface{} `json:"data,omitempty" yaml:"data,omitempty"`
+	Raw      string      `json:"raw,omitempty" yaml:"raw,omitempty"`
+	Error    string      `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// decodeTPRequest returns TPRequest in payload.
+// Payload must be encoded from TPRequest exactly if strict.
+func decodeTPRequest(raw []byte, strict bool) (*tp.TPRequest, bool) {
+	req := new(tp.TPRequest)
+	if err := proto.Unmarshal(raw, req); err != nil {
+		return nil, false
+	}
+
+	if strict {
+		tmp, err := proto.Marshal(req)
+		if err != nil || !bytes.Equal(tmp, raw) {
+			return nil, false
+		}
+	}
+
+	return req, true
+}
+
+// unmarshal decodes raw in encoding of payload.
+func (p *Payload) unmarshal(raw []byte, pb proto.Message) error {
+	c, err := tp.CodecOf(p.Encoding)
+	if err != nil {
+		return err
+	}
+	return c.Unmarshal(raw, pb)
+}
+
+// decode payload with registered protobuf type t.
+func (p *Payload) decode(t reflect.Type, raw []byte) {
+	if t == nil {
+		p.Raw = base64.StdEncoding.EncodeToString(raw)
+		return
+	}
+
+	pb := reflect.New(t).Interface().(proto.Message)
+	p.Type = proto.MessageName(pb)
+
+	if err := p.unmarshal(raw, pb); err != nil {
+		p.Raw = base64.StdEncoding.EncodeToString(raw)
+		p.Error = err.Error()
+		return
+	}
+
+	data, err := toGeneric(pb)
+	if err != nil {
+		p.Raw = base64.StdEncoding.EncodeToString(raw)
+		p.Error = err.Error()
+		return
+	}
+	p.Data = data
+}
+
+// DecodePayload returns decoded payload of a transaction in some family.
+func DecodePayload(family string, raw []byte) *Payload {
+	ret := &Payload{Size: len(raw)}
+
+	t, known, tpreq := lookup(family, 0)
+	if known && !tpreq {
+		ret.decode(t, raw)
+		return ret
+	}
+
+	// payloads of unknown families are treated as TPRequest only if encoded exactly.
+	req, ok := decodeTPRequest(raw, !known)
+	if !ok {
+		ret.decode(nil, raw)
+		return ret
+	}
+
+	cmd := req.Cmd
+	ret.Cmd = &cmd
+	ret.Encoding = req.Encoding
+	t, _, _ = lookup(family, cmd)
+	ret.decode(t, req.Payload)
+	return ret
+}
+
+// ----------------------------------------------------------------------------
+
+// TxHeader is a decoded transaction header.
+type TxHeader struct {
+	FamilyName       string   `json:"family_name" yaml:"family_name"`
+	FamilyVersion    string   `json:"family_version" yaml:"family_version"`
+	SignerPublicKey  string   `json:"signer_public_key" yaml:"signer_public_key"`
+	SignerWallet     string   `json:"signer_wallet,omitempty" yaml:"signer_wallet,omitempty"`
+	BatcherPublicKey string   `json:"batcher_public_key" yaml:"batcher_public_key"`
+	BatcherWallet    string   `json:"batcher_wallet,omitempty" yaml:"batcher_wallet,omitempty"`
+	Inputs           []string `json:"inputs" yaml:"inputs"`
+	Outputs          []string `json:"outputs" yaml:"outputs"`
+	Dependencies     []string `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	Nonce            string   `json:"nonce" yaml:"nonce"`
+	PayloadSha512    string   `json:"payload_sha512" yaml:"payload_sha512"`
+}
+
+// Transaction is a decoded transaction.
+type Transaction struct {
+	ID      string    `json:"id" yaml:"id"`
+	Header  *TxHeader `json:"header,omitempty" yaml:"header,omitempty"`
+	Payload *Payload  `json:"payload,omitempty" yaml:"payload,omitempty"`
+	Error   string    `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func newTransaction(id string, header *TxHeader, payload []byte) *Transaction {
+	return &Transaction{
+		ID:      id,
+		Header:  header,
+		Payload: DecodePayload(header.FamilyName, payload),
+	}
+}
+
+// DecodeTransaction returns a decoded transaction.
+func DecodeTransaction(x *transaction_pb2.Transaction) *Transaction {
+	h := new(transaction_pb2.TransactionHeader)
+	if err := proto.Unmarshal(x.Header, h); err != nil {
+		return &Transaction{ID: x.HeaderSignature, Error: err.Error()}
+	}
+
+	return newTransaction(x.HeaderSignature, &TxHeader{
+		FamilyName:       h.FamilyName,
+		FamilyVersion:    h.FamilyVersion,
+		SignerPublicKey:  h.SignerPublicKey,
+		SignerWallet:     walletOf(h.SignerPublicKey),
+		BatcherPublicKey: h.BatcherPublicKey,
+		BatcherWallet:    walletOf(h.BatcherPublicKey),
+		Inputs:           h.Inputs,
+		Outputs:          h.Outputs,
+		Dependencies:     h.Dependencies,
+		Nonce:            h.Nonce,
+		PayloadSha512:    h.PayloadSha512,
+	}, x.Payload)
+}
+
+// ----------------------------------------------------------------------------
+
+// BatchHeader is a decoded batch header.
+type BatchHeader struct {
+	SignerPublicKey string   `json:"signer_public_key" yaml:"signer_public_key"`
+	SignerWallet    string   `json:"signer_wallet,omitempty" yaml:"signer_wallet,omitempty"`
+	TransactionIDs  []string `json:"transaction_ids" yaml:"transaction_ids"`
+}
+
+// Batch is a decoded batch.
+type Batch struct {
+	ID           string         `json:"id" yaml:"id"`
+	Header       *BatchHeader   `json:"header,omitempty" yaml:"header,omitempty"`
+	Transactions []*Transaction `json:"transactions" yaml:"transactions"`
+	Error        string         `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// DecodeBatch returns a decoded batch.
+func DecodeBatch(x *batch_pb2.Batch) *Batch {
+	ret := &Batch{ID: x.HeaderSignature}
+
+	h := new(batch_pb2.BatchHeader)
+	if err := proto.Unmarshal(x.Header, h); err != nil {
+		ret.Error = err.Error()
+	} else {
+		ret.Header = &BatchHeader{
+			SignerPublicKey: h.SignerPublicKey,
+			SignerWallet:    walletOf(h.SignerPublicKey),
+			TransactionIDs:  h.TransactionIds,
+		}
+	}
+
+	ret.Transactions = make([]*Transaction, len(x.Transactions))
+	for i, tx := range x.Transactions {
+		ret.Transactions[i] = DecodeTransaction(tx)
+	}
+
+	return ret
+}
+
+// BatchList is a decoded batch list.
+type BatchList struct {
+	Batches []*Batch `json:"batches" yaml:"batches"`
+}
+
+// DecodeBatchList returns a decoded batch list.
+func DecodeBatchList(x *batch_pb2.BatchList) *BatchList {
+	ret := &BatchList{Batches: make([]*Batch, len(x.Batches))}
+	for i, b := range x.Batches {
+		ret.Batches[i] = DecodeBatch(b)
+	}
+	return ret
+}
+
+// ----------------------------------------------------------------------------
+
+// BlockHeader is a decoded block header.
+type BlockHeader struct {
+	BlockNum        uint64   `json:"block_num" yaml:"block_num"`
+	PreviousBlockID string   `json:"previous_block_id" yaml:"previous_block_id"`
+	SignerPublicKey string   `json:"signer_public_key" yaml:"signer_public_key"`
+	SignerWallet    string   `json:"signer_wallet,omitempty" yaml:"signer_wallet,omitempty"`
+	BatchIDs        []string `json:"batch_ids" yaml:"batch_ids"`
+	Consensus       string   `json:"consensus" yaml:"consensus"`
+	StateRootHash   string   `json:"state_root_hash" yaml:"state_root_hash"`
+}
+
+// Block is a decoded block.
+type Block struct {
+	ID      string       `json:"id" yaml:"id"`
+	Header  *BlockHeader `json:"header,omitempty" yaml:"header,omitempty"`
+	Batches []*Batch     `json:"batches" yaml:"batches"`
+	Error   string       `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// DecodeBlock returns a decoded block.
+func DecodeBlock(x *block_pb2.Block) *Block {
+	ret := &Block{ID: x.HeaderSignature}
+
+	h := new(block_pb2.BlockHeader)
+	if err := proto.Unmarshal(x.Header, h); err != nil {
+		ret.Error = err.Error()
+	} else {
+		ret.Header = &BlockHeader{
+			BlockNum:        h.BlockNum,
+			PreviousBlockID: h.PreviousBlockId,
+			SignerPublicKey: h.SignerPublicKey,
+			SignerWallet:    walletOf(h.SignerPublicKey),
+			BatchIDs:        h.BatchIds,
+			Consensus:       base64.StdEncoding.EncodeToString(h.Consensus),
+			StateRootHash:   h.StateRootHash,
+		}
+	}
+
+	ret.Batches = make([]*Batch, len(x.Batches))
+	for i, b := range x.Batches {
+		ret.Batches[i] = DecodeBatch(b)
+	}
+
+	return ret
+}
@@ -0,0 +1,70 @@
+package dump
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dairaga/sawtk/signing"
+	"github.com/dairaga/sawtk/tp"
+	"github.com/dairaga/sawtk/tx"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+func TestDecodeBatchList(t *testing.T) {
+	Register("dumptest", 7, new(transaction_pb2.TransactionHeader))
+
+	req, err := tp.NewTPRequest(7, &transaction_pb2.TransactionHeader{Nonce: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := tx.New("dumptest", "1.0", req, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer := signing.GenerateSignerFromCode("dump")
+	list, err := data.ToBatches(tx.NewBatchBuilder(signer), tx.NewBuilder(signer.GetPublicKey().AsHex(), signer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := proto.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Decode(raw, KindAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x := v.(*BatchList).Batches[0].Transactions[0]
+	if x.Header.SignerWallet == "" || x.Payload.Cmd == nil || *x.Payload.Cmd != 7 {
+		t.Fatalf("transaction not decoded: %+v", x)
+	}
+
+	out, err := YAML(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(out), "nonce: hello") {
+		t.Errorf("payload not decoded: %s", out)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	raw := `{"data": [{"header": {"signer_public_key": "03511c83916ac338835b07f6b9f7c0aa10b7b427b48e16b5e91360c919c9cf60cb", "transaction_ids": ["a"]}, "header_signature": "a", "transactions": []}]}`
+
+	v, err := Decode([]byte(raw), KindAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := v.([]interface{})[0].(*Batch)
+	if b.Header.SignerWallet != "1Lpgbz8o24ENRsZD3Rr5fVzJr2Ln4BBi5F" {
+		t.Errorf("wallet want 1Lpgbz8o24ENRsZD3Rr5fVzJr2Ln4BBi5F, but %q", b.Header.SignerWallet)
+	}
+}
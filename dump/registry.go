@@ -0,0 +1,70 @@
+package dump
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// family payload types.
+type family struct {
+	payload reflect.Type           // payload without TPRequest.
+	cmds    map[int32]reflect.Type // payload of TPRequest for each command.
+}
+
+var (
+	mutex    sync.RWMutex
+	families = make(map[string]*family)
+)
+
+// elem returns element type of a protobuf message pointer.
+func elem(pb proto.Message) reflect.Type {
+	t := reflect.TypeOf(pb)
+	if t == nil || t.Kind() != reflect.Ptr {
+		panic("pb must be a pointer of protobuf message")
+	}
+	return t.Elem()
+}
+
+func getFamily(name string) *family {
+	f, ok := families[name]
+	if !ok {
+		f = &family{cmds: make(map[int32]reflect.Type)}
+		families[name] = f
+	}
+	return f
+}
+
+// Register registers payload type of a command in a family using SawTK TPRequest.
+func Register(name string, cmd int32, pb proto.Message) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	getFamily(name).cmds[cmd] = elem(pb)
+}
+
+// RegisterPayload registers payload type of a family not using SawTK TPRequest.
+func RegisterPayload(name string, pb proto.Message) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	getFamily(name).payload = elem(pb)
+}
+
+// lookup returns payload type of a family and command, and whether the family uses TPRequest.
+func lookup(name string, cmd int32) (t reflect.Type, known, tpreq bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	f, ok := families[name]
+	if !ok {
+		return nil, false, false
+	}
+
+	if f.payload != nil {
+		return f.payload, true, false
+	}
+
+	return f.cmds[cmd], true, true
+}
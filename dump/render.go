@@ -0,0 +1,68 @@
+package dump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/batch_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/block_pb2"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Kinds of raw data.
+const (
+	KindAuto      = "auto"
+	KindBatchList = "batchlist"
+	KindBatch     = "batch"
+	KindBlock     = "block"
+	KindJSON      = "json"
+)
+
+// Decode returns decoded data in kind.
+// Raw data in JSON are from sawtooth restful api, and others are in protobuf.
+// Kind auto treats JSON as restful api data, and protobuf as batch list.
+func Decode(raw []byte, kind string) (interface{}, error) {
+	if kind == KindAuto {
+		kind = KindBatchList
+		if tmp := bytes.TrimSpace(raw); len(tmp) > 0 && tmp[0] == '{' {
+			kind = KindJSON
+		}
+	}
+
+	switch kind {
+	case KindJSON:
+		return DecodeJSON(raw)
+	case KindBatchList:
+		x := new(batch_pb2.BatchList)
+		if err := proto.Unmarshal(raw, x); err != nil {
+			return nil, err
+		}
+		return DecodeBatchList(x), nil
+	case KindBatch:
+		x := new(batch_pb2.Batch)
+		if err := proto.Unmarshal(raw, x); err != nil {
+			return nil, err
+		}
+		return DecodeBatch(x), nil
+	case KindBlock:
+		x := new(block_pb2.Block)
+		if err := proto.Unmarshal(raw, x); err != nil {
+			return nil, err
+		}
+		return DecodeBlock(x), nil
+	}
+
+	return nil, fmt.Errorf("unknown kind: %s", kind)
+}
+
+// JSON returns decoded data in indented JSON.
+func JSON(v interface{}) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// YAML returns decoded data in YAML.
+func YAML(v interface{}) ([]byte, error) {
+	return yaml.Marshal(v)
+}
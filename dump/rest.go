@@ -0,0 +1,164 @@
+package dump
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/dairaga/sawtk/client"
+)
+
+// FromRESTTransaction returns a decoded transaction from sawtooth restful api.
+func FromRESTTransaction(x *client.Transaction) *Transaction {
+	if x.Header == nil {
+		return &Transaction{ID: x.HeaderSignature, Error: "header is missing"}
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(x.Payload)
+	if err != nil {
+		return &Transaction{ID: x.HeaderSignature, Error: err.Error()}
+	}
+
+	return newTransaction(x.HeaderSignature, &TxHeader{
+		FamilyName:       x.Header.FamilyName,
+		FamilyVersion:    x.Header.FamilyVersion,
+		SignerPublicKey:  x.Header.SignerPublicKey,
+		SignerWallet:     walletOf(x.Header.SignerPublicKey),
+		BatcherPublicKey: x.Header.BatcherPublicKey,
+		BatcherWallet:    walletOf(x.Header.BatcherPublicKey),
+		Inputs:           x.Header.Inputs,
+		Outputs:          x.Header.Outputs,
+		Dependencies:     x.Header.Dependencies,
+		Nonce:            x.Header.Nonce,
+		PayloadSha512:    x.Header.PayloadSha512,
+	}, payload)
+}
+
+// FromRESTBatch returns a decoded batch from sawtooth restful api.
+func FromRESTBatch(x *client.Batch) *Batch {
+	ret := &Batch{ID: x.HeaderSignature}
+
+	if x.Header != nil {
+		ret.Header = &BatchHeader{
+			SignerPublicKey: x.Header.SignerPublicKey,
+			SignerWallet:    walletOf(x.Header.SignerPublicKey),
+			TransactionIDs:  x.Header.TransactionIds,
+		}
+	}
+
+	ret.Transactions = make([]*Transaction, len(x.Transactions))
+	for i := range x.Transactions {
+		ret.Transactions[i] = FromRESTTransaction(&x.Transactions[i])
+	}
+
+	return ret
+}
+
+// FromRESTBlock returns a decoded block from sawtooth restful api.
+func FromRESTBlock(x *client.Block) *Block {
+	ret := &Block{ID: x.HeaderSignature}
+
+	if x.Header != nil {
+		ret.Header = &BlockHeader{
+			BlockNum:        uint64(x.Header.BlockNum),
+			PreviousBlockID: x.Header.PreviousBlockID,
+			SignerPublicKey: x.Header.SignerPublicKey,
+			SignerWallet:    walletOf(x.Header.SignerPublicKey),
+			BatchIDs:        x.Header.BatchIds,
+			Consensus:       x.Header.Consensus,
+			StateRootHash:   x.Header.StateRootHash,
+		}
+	}
+
+	ret.Batches = make([]*Batch, len(x.Batches))
+	for i := range x.Batches {
+		ret.Batches[i] = FromRESTBatch(&x.Batches[i])
+	}
+
+	return ret
+}
+
+// ----------------------------------------------------------------------------
+
+// restItem returns decoded block, batch, transaction or batch list in JSON.
+func restItem(raw json.RawMessage) (interface{}, error) {
+	keys := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, err
+	}
+
+	_, hasHeader := keys["header"]
+	_, hasBatches := keys["batches"]
+	_, hasTxs := keys["transactions"]
+	_, hasPayload := keys["payload"]
+
+	switch {
+	case hasHeader && hasBatches:
+		x := new(client.Block)
+		if err := json.Unmarshal(raw, x); err != nil {
+			return nil, err
+		}
+		return FromRESTBlock(x), nil
+	case hasHeader && hasTxs:
+		x := new(client.Batch)
+		if err := json.Unmarshal(raw, x); err != nil {
+			return nil, err
+		}
+		return FromRESTBatch(x), nil
+	case hasHeader && hasPayload:
+		x := new(client.Transaction)
+		if err := json.Unmarshal(raw, x); err != nil {
+			return nil, err
+		}
+		return FromRESTTransaction(x), nil
+	case hasBatches:
+		x := new(client.BatchList)
+		if err := json.Unmarshal(raw, x); err != nil {
+			return nil, err
+		}
+
+		ret := &BatchList{Batches: make([]*Batch, len(x.Batches))}
+		for i := range x.Batches {
+			ret.Batches[i] = FromRESTBatch(&x.Batches[i])
+		}
+		return ret, nil
+	}
+
+	return nil, errors.New("unknown sawtooth restful api data")
+}
+
+// DecodeJSON returns decoded data from sawtooth restful api JSON.
+// It accepts responses with data, or a bare block, batch, transaction and batch list.
+func DecodeJSON(raw []byte) (interface{}, error) {
+	resp := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Data) <= 0 {
+		return restItem(raw)
+	}
+
+	if resp.Data[0] != '[' {
+		return restItem(resp.Data)
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(resp.Data, &items); err != nil {
+		return nil, err
+	}
+
+	ret := make([]interface{}, len(items))
+	for i, x := range items {
+		item, err := restItem(x)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = item
+	}
+
+	return ret, nil
+}
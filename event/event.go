@@ -0,0 +1,157 @@
+/*
+Package event registers Sawtooth event types with their protobuf data and standard attributes.
+
+Transaction handlers in tp validate emitted events against registered types,
+and subscribers decode data of received events into registered messages.
+*/
+package event
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/events_pb2"
+)
+
+// Type is a registered event type.
+type Type struct {
+	Name       string
+	Attributes []string     // standard attribute keys every event must have.
+	typ        reflect.Type // element type of data, nil means no data.
+}
+
+func (t *Type) String() string {
+	msg := ""
+	if t.typ != nil {
+		msg = proto.MessageName(t.New())
+	}
+	return fmt.Sprintf(`{"type": "%s", "message": "%s", "attributes": %q}`, t.Name, msg, t.Attributes)
+}
+
+// New returns a new empty message of event data, or nil if event has no data.
+func (t *Type) New() proto.Message {
+	if t.typ == nil {
+		return nil
+	}
+	return reflect.New(t.typ).Interface().(proto.Message)
+}
+
+// Decode returns message of event data.
+func (t *Type) Decode(data []byte) (proto.Message, error) {
+	m := t.New()
+	if m == nil {
+		if len(data) > 0 {
+			return nil, fmt.Errorf("event %s has no data, but got %d bytes", t.Name, len(data))
+		}
+		return nil, nil
+	}
+
+	if err := proto.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("event %s unmarshal %s: %v", t.Name, proto.MessageName(m), err)
+	}
+	return m, nil
+}
+
+// Check returns error if data is not registered message, or standard attributes are not in keys.
+func (t *Type) Check(data proto.Message, keys []string) error {
+	if t.typ == nil {
+		if data != nil && !reflect.ValueOf(data).IsNil() {
+			return fmt.Errorf("event %s has no data, but got %T", t.Name, data)
+		}
+	} else if reflect.TypeOf(data) != reflect.PtrTo(t.typ) {
+		return fmt.Errorf("event %s data must be %v, but got %T", t.Name, reflect.PtrTo(t.typ), data)
+	}
+
+	for _, a := range t.Attributes {
+		found := false
+		for _, k := range keys {
+			if k == a {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("event %s must have attribute %s", t.Name, a)
+		}
+	}
+
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+
+var (
+	mutex sync.RWMutex
+	types = make(map[string]*Type)
+)
+
+// Register registers event type with message of data and standard attribute keys.
+// pb is nil means event has no data.
+func Register(name string, pb proto.Message, attributes ...string) *Type {
+	t := &Type{
+		Name:       name,
+		Attributes: attributes,
+	}
+
+	if pb != nil {
+		rt := reflect.TypeOf(pb)
+		if rt.Kind() != reflect.Ptr {
+			panic("pb must be a pointer of protobuf message")
+		}
+		t.typ = rt.Elem()
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, ok := types[name]; ok {
+		panic(fmt.Sprintf("event %s is registered", name))
+	}
+	types[name] = t
+	return t
+}
+
+// unregister removes registered event types of names. It is for tests registering the same names in each run.
+func unregister(names ...string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, x := range names {
+		delete(types, x)
+	}
+}
+
+// Lookup returns registered event type of name.
+func Lookup(name string) (*Type, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	t, ok := types[name]
+	return t, ok
+}
+
+// Types returns sorted names of registered event types.
+func Types() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	ret := make([]string, 0, len(types))
+	for k := range types {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Decode returns message of data in event.
+func Decode(evt *events_pb2.Event) (proto.Message, error) {
+	t, ok := Lookup(evt.EventType)
+	if !ok {
+		return nil, fmt.Errorf("event %s is not registered", evt.EventType)
+	}
+	return t.Decode(evt.Data)
+}
@@ -0,0 +1,48 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/events_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/setting_pb2"
+)
+
+func TestRegistry(t *testing.T) {
+	const updated, empty = "eventtest/updated", "eventtest/empty"
+	defer unregister(updated, empty)
+
+	typ := Register(updated, new(setting_pb2.Setting_Entry), "key")
+
+	if err := typ.Check(&setting_pb2.Setting_Entry{}, []string{"key"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := typ.Check(&setting_pb2.Setting{}, []string{"key"}); err == nil {
+		t.Fatal("check other message must fail")
+	}
+
+	if err := typ.Check(&setting_pb2.Setting_Entry{}, nil); err == nil {
+		t.Fatal("check without standard attributes must fail")
+	}
+
+	want := &setting_pb2.Setting_Entry{Key: "a", Value: "1"}
+	data, err := proto.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := Decode(&events_pb2.Event{EventType: updated, Data: data})
+	if err != nil || !proto.Equal(msg, want) {
+		t.Fatalf("decode want %v, but %v %v", want, msg, err)
+	}
+
+	if _, err := Decode(&events_pb2.Event{EventType: "eventtest/unknown"}); err == nil {
+		t.Fatal("decode unregistered event must fail")
+	}
+
+	Register(empty, nil)
+	if typ, _ := Lookup(empty); typ.Check(nil, nil) != nil || typ.Check(want, nil) == nil {
+		t.Fatal("event without data must only accept nil")
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Package gen generates typed code of a SawTK family from a family definition.
+
+A family definition is a YAML file:
+
+	package: wallet                       # package of generated code.
+	family: wallet                        # family name.
+	versions: ["1.0"]
+	namespace: wallet                     # namespace name, see ns.New.
+	proto: github.com/acme/wallet/pb      # import path of payload messages, empty if in the same package.
+	commands:
+	  - name: Deposit
+	    cmd: 1
+	    payload: DepositRequest           # payload message.
+	    inputs: [Account]                 # payload fields as keys of addresses, or * for whole namespace.
+	    outputs: [Account]
+
+Generated code contains command constants, family and namespace, a Handlers interface with
+a method per command, NewHandler routing commands to Handlers, and a typed client.
+Handler skeleton and RequestValidator stubs are generated only if files do not exist.
+*/
+package gen
+
+import (
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"path"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+// isIdent returns s is a Go identifier or not.
+func isIdent(s string) bool {
+	if s == "" || token.Lookup(s).IsKeyword() {
+		return false
+	}
+
+	for i, c := range s {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isExported returns s is an exported Go identifier or not.
+func isExported(s string) bool {
+	return isIdent(s) && unicode.IsUpper([]rune(s)[0])
+}
+
+// Command is a command of family.
+type Command struct {
+	Name    string   `yaml:"name"`
+	Cmd     int32    `yaml:"cmd"`
+	Payload string   `yaml:"payload"`
+	Inputs  []string `yaml:"inputs,omitempty"`
+	Outputs []string `yaml:"outputs,omitempty"`
+}
+
+// Definition is a family definition.
+type Definition struct {
+	Package   string    `yaml:"package"`
+	Family    string    `yaml:"family"`
+	Versions  []string  `yaml:"versions"`
+	Namespace string    `yaml:"namespace"`
+	Proto     string    `yaml:"proto,omitempty"`
+	Commands  []Command `yaml:"commands"`
+}
+
+// Load returns family definition in file.
+func Load(file string) (*Definition, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Parse returns family definition in YAML data.
+func Parse(data []byte) (*Definition, error) {
+	d := new(Definition)
+	if err := yaml.UnmarshalStrict(data, d); err != nil {
+		return nil, err
+	}
+
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Validate returns error if definition is incomplete.
+func (d *Definition) Validate() error {
+	if !isIdent(d.Package) {
+		return fmt.Errorf("package %q is not an identifier", d.Package)
+	}
+
+	if d.Family == "" || d.Namespace == "" || len(d.Versions) <= 0 {
+		return fmt.Errorf("family, namespace and versions are required")
+	}
+
+	if len(d.Commands) <= 0 {
+		return fmt.Errorf("family %s has no commands", d.Family)
+	}
+
+	names := make(map[string]bool)
+	cmds := make(map[int32]bool)
+
+	for _, c := range d.Commands {
+		if !isExported(c.Name) {
+			return fmt.Errorf("command name %q must be an exported identifier", c.Name)
+		}
+
+		if !isIdent(c.Payload) {
+			return fmt.Errorf("command %s: payload %q is not an identifier", c.Name, c.Payload)
+		}
+
+		if names[c.Name] || cmds[c.Cmd] {
+			return fmt.Errorf("command %s (%d) is duplicated", c.Name, c.Cmd)
+		}
+		names[c.Name] = true
+		cmds[c.Cmd] = true
+
+		for _, f := range append(append([]string{}, c.Inputs...), c.Outputs...) {
+			if f != "*" && !isExported(f) {
+				return fmt.Errorf("command %s: address rule %q must be * or an exported field", c.Name, f)
+			}
+		}
+	}
+
+	return nil
+}
+
+// protoName returns package name of payload messages.
+func (d *Definition) protoName() string {
+	if d.Proto == "" {
+		return ""
+	}
+	return path.Base(d.Proto)
+}
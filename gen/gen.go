@@ -0,0 +1,290 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// File is a generated file.
+type File struct {
+	Name      string
+	Data      []byte
+	Overwrite bool // false means file is edited by user and kept if exists.
+}
+
+var funcs = template.FuncMap{
+	"addrs": func(rules []string) string {
+		if len(rules) <= 0 {
+			return "nil"
+		}
+
+		tmp := make([]string, len(rules))
+		for i, r := range rules {
+			if r == "*" {
+				tmp[i] = "Namespace.Prefix()"
+			} else {
+				tmp[i] = fmt.Sprintf("Namespace.MakeAddress(fmt.Sprint(msg.Get%s()))", r)
+			}
+		}
+		return "[]string{" + strings.Join(tmp, ", ") + "}"
+	},
+	"quote": func(ss []string) string {
+		tmp := make([]string, len(ss))
+		for i, s := range ss {
+			tmp[i] = fmt.Sprintf("%q", s)
+		}
+		return strings.Join(tmp, ", ")
+	},
+}
+
+// usesFmt returns generated client needs fmt package or not.
+func (d *Definition) usesFmt() bool {
+	for _, c := range d.Commands {
+		for _, r := range append(append([]string{}, c.Inputs...), c.Outputs...) {
+			if r != "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var genTmpl = template.Must(template.New("gen").Funcs(funcs).Parse(`// Code generated by sawtk gen. DO NOT EDIT.
+
+package {{.Def.Package}}
+
+import (
+	"context"
+{{- if .Def.UsesFmt}}
+	"fmt"
+{{- end}}
+
+	"github.com/dairaga/sawtk/client"
+	"github.com/dairaga/sawtk/ns"
+	"github.com/dairaga/sawtk/signing"
+	"github.com/dairaga/sawtk/tp"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+{{- if .Def.Proto}}
+
+	{{.PB}} "{{.Def.Proto}}"
+{{- end}}
+)
+
+// Commands of {{.Def.Family}} family.
+const (
+{{- range .Def.Commands}}
+	Cmd{{.Name}} int32 = {{.Cmd}}
+{{- end}}
+)
+
+// Namespace is namespace of {{.Def.Family}} family.
+var Namespace = ns.New("{{.Def.Namespace}}")
+
+// Family is {{.Def.Family}} family.
+var Family = tp.NewFamily("{{.Def.Family}}", []string{ {{- quote .Def.Versions -}} }, []string{Namespace.Prefix()})
+
+// Handlers handles commands of {{.Def.Family}} family.
+type Handlers interface {
+{{- range .Def.Commands}}
+	{{.Name}}(ctx *tp.Context, msg *{{$.Prefix}}{{.Payload}}) *processor.InvalidTransactionError
+{{- end}}
+}
+
+// decodeRequest unmarshals payload into msg, and validates msg if it is a RequestValidator.
+func decodeRequest(req *tp.TPRequest, msg proto.Message) *processor.InvalidTransactionError {
+	if req.Payload == nil {
+		return tp.BadParameters.TxErrorf("payload is nil")
+	}
+
+	if err := req.Unmarshal(msg); err != nil {
+		return tp.Unmarshal.TxErrore(err)
+	}
+
+	if v, ok := msg.(tp.RequestValidator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// NewHandler returns a handler routing commands to h.
+func NewHandler(h Handlers) *tp.Handler {
+	handler := tp.NewHandler(Family)
+{{- range .Def.Commands}}
+
+	handler.Add(Cmd{{.Name}}, func(ctx *tp.Context, req *tp.TPRequest) *processor.InvalidTransactionError {
+		msg := new({{$.Prefix}}{{.Payload}})
+		if err := decodeRequest(req, msg); err != nil {
+			return err
+		}
+		return h.{{.Name}}(ctx, msg)
+	})
+{{- end}}
+
+	return handler
+}
+
+// Client is a typed client of {{.Def.Family}} family.
+type Client struct {
+	*tp.FamilyClient
+}
+
+// NewClient returns a client of {{.Def.Family}} family.
+func NewClient(signer *signing.Signer, cli *client.Client) *Client {
+	return &Client{tp.NewFamilyClient(Family, Namespace, signer, cli)}
+}
+{{- range .Def.Commands}}
+
+// {{.Name}} submits command {{.Name}} and waits until the batch is committed or invalid.
+func (c *Client) {{.Name}}(ctx context.Context, msg *{{$.Prefix}}{{.Payload}}) (*client.BatchStatus, error) {
+	return c.Submit(ctx, Cmd{{.Name}}, msg, {{addrs .Inputs}}, {{addrs .Outputs}})
+}
+{{- end}}
+`))
+
+var handlerTmpl = template.Must(template.New("handler").Parse(`package {{.Def.Package}}
+
+import (
+	"github.com/dairaga/sawtk/tp"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+{{- if .Def.Proto}}
+
+	{{.PB}} "{{.Def.Proto}}"
+{{- end}}
+)
+
+// handlers implements Handlers of {{.Def.Family}} family.
+type handlers struct{}
+
+var _ Handlers = (*handlers)(nil)
+{{- range .Def.Commands}}
+
+// {{.Name}} handles command {{.Name}}.
+func (h *handlers) {{.Name}}(ctx *tp.Context, msg *{{$.Prefix}}{{.Payload}}) *processor.InvalidTransactionError {
+	return tp.Internal.TxErrorf("{{.Name}} is not implemented")
+}
+{{- end}}
+`))
+
+var validateTmpl = template.Must(template.New("validate").Parse(`package {{.Package}}
+
+import (
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+)
+{{- range .Payloads}}
+
+// Validate implements tp.RequestValidator.
+func (m *{{.}}) Validate() *processor.InvalidTransactionError {
+	return nil
+}
+{{- end}}
+`))
+
+// ----------------------------------------------------------------------------
+
+type genData struct {
+	Def    *defData
+	PB     string
+	Prefix string
+}
+
+type defData struct {
+	*Definition
+	UsesFmt bool
+}
+
+func execute(tmpl *template.Template, data interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format %s: %v\n%s", tmpl.Name(), err, buf.Bytes())
+	}
+	return src, nil
+}
+
+// Generate returns generated files of definition.
+// Files are named after family: <family>_gen.go, <family>_handler.go and <family>_validate.go.
+// Validator stubs are generated only if payload messages are in the same package,
+// one per payload message, and are kept if file exists.
+func Generate(d *Definition) ([]File, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	data := &genData{
+		Def: &defData{Definition: d, UsesFmt: d.usesFmt()},
+		PB:  d.protoName(),
+	}
+	if data.PB != "" {
+		data.Prefix = data.PB + "."
+	}
+
+	base := strings.Replace(strings.ToLower(d.Family), "-", "_", -1)
+
+	src, err := execute(genTmpl, data)
+	if err != nil {
+		return nil, err
+	}
+	files := []File{{Name: base + "_gen.go", Data: src, Overwrite: true}}
+
+	if src, err = execute(handlerTmpl, data); err != nil {
+		return nil, err
+	}
+	files = append(files, File{Name: base + "_handler.go", Data: src})
+
+	if d.Proto == "" {
+		var payloads []string
+		seen := make(map[string]bool)
+		for _, c := range d.Commands {
+			if !seen[c.Payload] {
+				seen[c.Payload] = true
+				payloads = append(payloads, c.Payload)
+			}
+		}
+
+		src, err = execute(validateTmpl, map[string]interface{}{
+			"Package":  d.Package,
+			"Payloads": payloads,
+		})
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: base + "_validate.go", Data: src})
+	}
+
+	return files, nil
+}
+
+// Write writes files into dir, and returns names of written files.
+// Directories in names of files are created, and files not to overwrite are skipped if they exist.
+func Write(dir string, files []File) ([]string, error) {
+	var written []string
+	for _, f := range files {
+		name := filepath.Join(dir, f.Name)
+		if !f.Overwrite {
+			if _, err := os.Stat(name); err == nil {
+				continue
+			}
+		}
+
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return written, err
+		}
+
+		if err := ioutil.WriteFile(name, f.Data, 0644); err != nil {
+			return written, err
+		}
+		written = append(written, name)
+	}
+	return written, nil
+}
@@ -0,0 +1,133 @@
+package gen
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const def = `
+package: settings
+family: mysettings
+versions: ["1.0"]
+namespace: mysettings
+proto: github.com/hyperledger/sawtooth-sdk-go/protobuf/setting_pb2
+commands:
+  - name: Put
+    cmd: 1
+    payload: Setting_Entry
+    inputs: [Key]
+    outputs: [Key]
+  - name: Reset
+    cmd: 2
+    payload: Setting
+    outputs: ["*"]
+`
+
+// parseFiles fails t if generated Go files are not valid Go code.
+func parseFiles(t *testing.T, files []File) {
+	t.Helper()
+	fset := token.NewFileSet()
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name, ".go") {
+			continue
+		}
+		if _, err := parser.ParseFile(fset, f.Name, f.Data, parser.AllErrors); err != nil {
+			t.Errorf("parse %s: %v\n%s", f.Name, err, f.Data)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	d, err := Parse([]byte(def))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Generate(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 || files[0].Name != "mysettings_gen.go" || files[1].Name != "mysettings_handler.go" {
+		t.Fatalf("files: %v", files)
+	}
+	parseFiles(t, files)
+
+	for _, want := range []string{
+		"CmdPut   int32 = 1",
+		"Put(ctx *tp.Context, msg *setting_pb2.Setting_Entry) *processor.InvalidTransactionError",
+		"c.Submit(ctx, CmdPut, msg, []string{Namespace.MakeAddress(fmt.Sprint(msg.GetKey()))}",
+		"c.Submit(ctx, CmdReset, msg, nil, []string{Namespace.Prefix()})",
+	} {
+		if !bytes.Contains(files[0].Data, []byte(want)) {
+			t.Errorf("generated code want %s, but\n%s", want, files[0].Data)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	handler := filepath.Join(dir, "mysettings_handler.go")
+	if err := ioutil.WriteFile(handler, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := Write(dir, files)
+	if err != nil || len(written) != 1 {
+		t.Fatalf("write: %v %v", written, err)
+	}
+
+	if data, _ := ioutil.ReadFile(handler); string(data) != "edited" {
+		t.Fatal("handler skeleton must not be overwritten")
+	}
+}
+
+func TestGenerateSamePackage(t *testing.T) {
+	d, err := Parse([]byte("package: wallet\nfamily: my-wallet\nversions: [\"1.0\"]\nnamespace: wallet\n" +
+		"commands: [{name: Deposit, cmd: 1, payload: DepositRequest, inputs: [Account], outputs: [Account]}]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Generate(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 3 || files[2].Name != "my_wallet_validate.go" || files[2].Overwrite {
+		t.Fatalf("files: %v", files)
+	}
+	parseFiles(t, files)
+
+	for _, f := range files {
+		want := 0
+		if f.Name == "my_wallet_validate.go" {
+			want = 1
+		}
+		if n := bytes.Count(f.Data, []byte(") Validate()")); n != want {
+			t.Errorf("%s must define %d Validate methods, but %d", f.Name, want, n)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, x := range []string{
+		"package: a\nfamily: a\nversions: [\"1.0\"]\nnamespace: a\n",
+		"package: a\nfamily: a\nversions: [\"1.0\"]\nnamespace: a\ncommands: [{name: put, cmd: 1, payload: A}]\n",
+		"package: a\nfamily: a\nversions: [\"1.0\"]\nnamespace: a\ncommands: [{name: Put, cmd: 1, payload: A}, {name: Get, cmd: 1, payload: A}]\n",
+		"package: a\nfamily: a\nversions: [\"1.0\"]\nnamespace: a\ncommands: [{name: Put, cmd: 1, payload: A, inputs: [key]}]\n",
+	} {
+		if _, err := Parse([]byte(x)); err == nil {
+			t.Errorf("definition must be invalid:\n%s", x)
+		}
+	}
+}
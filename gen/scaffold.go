@@ -0,0 +1,398 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Project is a new family project.
+type Project struct {
+	Module    string // module path of project.
+	Family    string // family name.
+	Namespace string // namespace name, family name if empty.
+	Version   string // family version, 1.0 if empty.
+}
+
+type projectData struct {
+	Project
+	Package string
+	Base    string
+}
+
+// packageOf returns package name from family name, ex: my-wallet is mywallet.
+func packageOf(family string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(family) {
+		if ('a' <= c && c <= 'z') || ('0' <= c && c <= '9' && b.Len() > 0) {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+var protoTmpl = template.Must(template.New("proto").Parse(`syntax = "proto3";
+
+package {{.Package}};
+
+option go_package = "{{.Module}};{{.Package}}";
+
+// 寫入指令
+message SetRequest {
+    string key = 1;     // 鍵值
+    string value = 2;   // 資料
+}
+
+// 刪除指令
+message DeleteRequest {
+    string key = 1;     // 鍵值
+}
+
+// 狀態資料
+message Record {
+    string key = 1;     // 鍵值
+    string value = 2;   // 資料
+}
+`))
+
+var familyTmpl = template.Must(template.New("family").Parse(`// Package {{.Package}} is transaction family {{.Family}}.
+package {{.Package}}
+
+//go:generate protoc -I . --go_out=paths=source_relative:. {{.Base}}.proto
+
+import (
+	"github.com/dairaga/sawtk/ns"
+	"github.com/dairaga/sawtk/tp"
+	"github.com/golang/protobuf/proto"
+)
+
+// Commands of {{.Family}} family.
+const (
+	CmdSet    int32 = 1
+	CmdDelete int32 = 2
+)
+
+// Namespace is namespace of {{.Family}} family.
+var Namespace = ns.New("{{.Namespace}}")
+
+// Family is {{.Family}} family.
+var Family = tp.NewFamily("{{.Family}}", []string{"{{.Version}}"}, []string{Namespace.Prefix()})
+
+// Records stores records by key.
+var Records = tp.NewRepository(Namespace,
+	func() proto.Message { return new(Record) },
+	func(m proto.Message) string { return m.(*Record).Key },
+)
+
+// NewHandler returns handler of {{.Family}} family.
+func NewHandler() *tp.Handler {
+	h := tp.NewHandler(Family)
+	h.Add(CmdSet, tp.MakeHandlerFunc(set))
+	h.Add(CmdDelete, tp.MakeHandlerFunc(del))
+	return h
+}
+`))
+
+var scaffoldHandlerTmpl = template.Must(template.New("handler").Parse(`package {{.Package}}
+
+import (
+	"github.com/dairaga/sawtk/tp"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+)
+
+// Validate implements tp.RequestValidator.
+func (m *SetRequest) Validate() *processor.InvalidTransactionError {
+	if m.Key == "" {
+		return tp.BadParameters.TxErrorf("key is required")
+	}
+	return nil
+}
+
+// Validate implements tp.RequestValidator.
+func (m *DeleteRequest) Validate() *processor.InvalidTransactionError {
+	if m.Key == "" {
+		return tp.BadParameters.TxErrorf("key is required")
+	}
+	return nil
+}
+
+// set stores value of key.
+func set(ctx *tp.Context, req *SetRequest) *processor.InvalidTransactionError {
+	return Records.Save(ctx, &Record{Key: req.Key, Value: req.Value})
+}
+
+// del removes key, or returns NotFound error if key does not exist.
+func del(ctx *tp.Context, req *DeleteRequest) *processor.InvalidTransactionError {
+	return Records.Delete(ctx, req.Key)
+}
+`))
+
+var clientTmpl = template.Must(template.New("client").Parse(`package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/dairaga/sawtk/client"
+	"github.com/dairaga/sawtk/signing"
+	"github.com/dairaga/sawtk/tp"
+)
+
+// Client submits commands of {{.Family}} family.
+type Client struct {
+	*tp.FamilyClient
+}
+
+// NewClient returns a client of {{.Family}} family.
+func NewClient(signer *signing.Signer, cli *client.Client) *Client {
+	return &Client{tp.NewFamilyClient(Family, Namespace, signer, cli)}
+}
+
+// Set stores value of key, and waits until the batch is committed or invalid.
+func (c *Client) Set(ctx context.Context, key, value string) (*client.BatchStatus, error) {
+	addr := Records.Address(key)
+	return c.Submit(ctx, CmdSet, &SetRequest{Key: key, Value: value}, []string{addr}, []string{addr})
+}
+
+// Delete removes key, and waits until the batch is committed or invalid.
+func (c *Client) Delete(ctx context.Context, key string) (*client.BatchStatus, error) {
+	addr := Records.Address(key)
+	return c.Submit(ctx, CmdDelete, &DeleteRequest{Key: key}, []string{addr}, []string{addr})
+}
+
+// Get returns record of key.
+func (c *Client) Get(key string) (*Record, error) {
+	r := new(Record)
+	if err := c.Client().StatePB(Records.Address(key), r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+`))
+
+var testTmpl = template.Must(template.New("test").Parse(`package {{.Package}}
+
+import (
+	"testing"
+
+	"github.com/dairaga/sawtk/tp"
+	"github.com/dairaga/sawtk/tptest"
+)
+
+func TestHandler(t *testing.T) {
+	hn := tptest.New(NewHandler())
+
+	hn.Invoke("alice", CmdSet, &SetRequest{Key: "k", Value: "v"}).AssertOK(t)
+	hn.AssertState(t, Records.Address("k"), &Record{Key: "k", Value: "v"})
+
+	hn.Invoke("alice", CmdSet, &SetRequest{Value: "v"}).AssertCode(t, tp.BadParameters)
+
+	hn.Invoke("alice", CmdDelete, &DeleteRequest{Key: "k"}).AssertOK(t)
+	hn.AssertState(t, Records.Address("k"), nil)
+
+	hn.Invoke("alice", CmdDelete, &DeleteRequest{Key: "k"}).AssertCode(t, tp.NotFound)
+}
+`))
+
+var mainTmpl = template.Must(template.New("main").Parse(`// Command {{.Base}}-tp is transaction processor of {{.Family}} family.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dairaga/log"
+	"github.com/dairaga/sawtk/tp"
+
+	family "{{.Module}}"
+)
+
+func main() {
+	endpoint := flag.String("endpoint", "tcp://localhost:4004", "validator endpoint")
+	addr := flag.String("http", ":8080", "address of health and metrics server, empty to disable")
+	flag.Parse()
+
+	r := tp.NewRunner(*endpoint).Add(family.NewHandler()).ListenHTTP(*addr)
+
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		<-sig
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := r.Stop(ctx); err != nil {
+			log.Errorf("stop: %v", err)
+		}
+	}()
+
+	if err := r.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
+`))
+
+var composeTmpl = template.Must(template.New("compose").Parse(`# local validator for {{.Family}} family.
+# transaction processor runs on host: go run ./cmd/{{.Base}}-tp
+version: "2.1"
+
+services:
+  settings-tp:
+    image: hyperledger/sawtooth-settings-tp:1.2
+    container_name: {{.Base}}-settings-tp
+    depends_on:
+      - validator
+    entrypoint: settings-tp -vv -C tcp://validator:4004
+
+  validator:
+    image: hyperledger/sawtooth-validator:1.2
+    container_name: {{.Base}}-validator
+    expose:
+      - 4004
+      - 5050
+      - 8800
+    ports:
+      - "4004:4004"
+    entrypoint: "bash -c \"\
+        sawadm keygen && \
+        sawtooth keygen my_key && \
+        sawset genesis -k /root/.sawtooth/keys/my_key.priv && \
+        sawset proposal create \
+          -k /root/.sawtooth/keys/my_key.priv \
+          sawtooth.consensus.algorithm.name=Devmode \
+          sawtooth.consensus.algorithm.version=0.1 \
+          -o config.batch && \
+        sawadm genesis config-genesis.batch config.batch && \
+        sawtooth-validator -vv \
+          --endpoint tcp://validator:8800 \
+          --bind component:tcp://eth0:4004 \
+          --bind network:tcp://eth0:8800 \
+          --bind consensus:tcp://eth0:5050 \
+        \""
+
+  devmode-engine:
+    image: hyperledger/sawtooth-devmode-engine-rust:1.2
+    container_name: {{.Base}}-devmode-engine
+    depends_on:
+      - validator
+    entrypoint: devmode-engine-rust -C tcp://validator:5050
+
+  rest-api:
+    image: hyperledger/sawtooth-rest-api:1.2
+    container_name: {{.Base}}-rest-api
+    depends_on:
+      - validator
+    ports:
+      - "8008:8008"
+    entrypoint: sawtooth-rest-api -C tcp://validator:4004 --bind rest-api:8008
+`))
+
+var makefileTmpl = template.Must(template.New("makefile").Parse(`.PHONY: all generate test up down clean
+
+all: generate
+	go build ./...
+
+generate:
+	go generate ./...
+	go mod tidy
+
+test: generate
+	go test ./...
+
+up:
+	docker-compose up -d
+
+down:
+	docker-compose down
+
+clean:
+	- rm *.pb.go
+`))
+
+// gomodTmpl requires the same versions as sawtk. github.com/dairaga/sawtk is added by go mod tidy.
+// Tagged sawtooth-sdk-go has no generated protobuf packages (*_pb2),
+// so it is replaced with a local checkout after running go generate in it, like sawtk does.
+var gomodTmpl = template.Must(template.New("gomod").Parse(`module {{.Module}}
+
+go 1.12
+
+require (
+	github.com/dairaga/log v0.0.0-20190611140521-2f471283f46f
+	github.com/golang/protobuf v1.3.2
+	github.com/hyperledger/sawtooth-sdk-go v0.1.2
+	github.com/pebbe/zmq4 v1.0.0
+)
+
+// sawtooth-sdk-go needs generated protobuf packages: run go generate in the checkout.
+replace github.com/hyperledger/sawtooth-sdk-go => ../../hyperledger/sawtooth-sdk-go
+`))
+
+// render executes template without formatting.
+func render(tmpl *template.Template, data interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Scaffold returns files of a ready-to-build family project with two commands, Set and Delete.
+// Files are proto definitions, handler, namespace, client, unit tests, a transaction processor command,
+// Makefile and docker-compose file of a local validator.
+// Names of files are relative to project directory, and no file overwrites an existing one.
+func Scaffold(p Project) ([]File, error) {
+	if p.Module == "" || p.Family == "" {
+		return nil, fmt.Errorf("module and family are required")
+	}
+	if p.Namespace == "" {
+		p.Namespace = p.Family
+	}
+	if p.Version == "" {
+		p.Version = "1.0"
+	}
+
+	data := &projectData{
+		Project: p,
+		Package: packageOf(p.Family),
+		Base:    strings.Replace(strings.ToLower(p.Family), "_", "-", -1),
+	}
+	if !isIdent(data.Package) {
+		return nil, fmt.Errorf("family %q can not be a package name", p.Family)
+	}
+
+	list := []struct {
+		name  string
+		tmpl  *template.Template
+		gofmt bool
+	}{
+		{"go.mod", gomodTmpl, false},
+		{data.Base + ".proto", protoTmpl, false},
+		{"family.go", familyTmpl, true},
+		{"handler.go", scaffoldHandlerTmpl, true},
+		{"handler_test.go", testTmpl, true},
+		{"client.go", clientTmpl, true},
+		{"cmd/" + data.Base + "-tp/main.go", mainTmpl, true},
+		{"Makefile", makefileTmpl, false},
+		{"docker-compose.yaml", composeTmpl, false},
+	}
+
+	files := make([]File, 0, len(list))
+	for _, x := range list {
+		var src []byte
+		var err error
+		if x.gofmt {
+			src, err = execute(x.tmpl, data)
+		} else {
+			src, err = render(x.tmpl, data)
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: x.name, Data: src})
+	}
+	return files, nil
+}
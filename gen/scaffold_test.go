@@ -0,0 +1,67 @@
+package gen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScaffold(t *testing.T) {
+	files, err := Scaffold(Project{Module: "github.com/acme/my-wallet", Family: "my-wallet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string][]byte)
+	for _, f := range files {
+		got[f.Name] = f.Data
+	}
+
+	for name, want := range map[string]string{
+		"go.mod":                   "replace github.com/hyperledger/sawtooth-sdk-go => ",
+		"my-wallet.proto":          `option go_package = "github.com/acme/my-wallet;mywallet";`,
+		"family.go":                `var Family = tp.NewFamily("my-wallet", []string{"1.0"}, []string{Namespace.Prefix()})`,
+		"handler.go":               "package mywallet",
+		"handler_test.go":          "tptest.New(NewHandler())",
+		"client.go":                "func NewClient(",
+		"cmd/my-wallet-tp/main.go": `family "github.com/acme/my-wallet"`,
+		"Makefile":                 "go generate ./...",
+		"docker-compose.yaml":      "hyperledger/sawtooth-validator",
+	} {
+		if !bytes.Contains(got[name], []byte(want)) {
+			t.Errorf("%s want %q, but\n%s", name, want, got[name])
+		}
+	}
+
+	if !bytes.HasPrefix(got["go.mod"], []byte("module github.com/acme/my-wallet\n")) {
+		t.Errorf("go.mod: %s", got["go.mod"])
+	}
+	parseFiles(t, files)
+
+	if _, err := Scaffold(Project{Module: "x", Family: "123"}); err == nil {
+		t.Error("family without letters must fail")
+	}
+
+	dir, err := ioutil.TempDir("", "scaffold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "handler.go"), []byte("package mywallet\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := Write(dir, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != len(files)-1 {
+		t.Errorf("existing handler.go must be kept: %v", written)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cmd", "my-wallet-tp", "main.go")); err != nil {
+		t.Error(err)
+	}
+}
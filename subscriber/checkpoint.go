@@ -0,0 +1,119 @@
+package subscriber
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/events_pb2"
+)
+
+// EvtTypBlockCommit is sawtooth block commit event.
+const EvtTypBlockCommit = "sawtooth/block-commit"
+
+// blockIDFile is checkpoint file of deprecated Subscriber.RecordBlockID.
+const blockIDFile = ".sub_block_id"
+
+// Checkpoint stores id of the last block whose events are all handled.
+type Checkpoint interface {
+	Load() (string, error) // returns empty string if no block is saved.
+	Save(blockID string) error
+}
+
+// ----------------------------------------------------------------------------
+
+// MemCheckpoint keeps block id in memory.
+type MemCheckpoint struct {
+	mux sync.Mutex
+	id  string
+}
+
+// NewMemCheckpoint returns an empty memory checkpoint.
+func NewMemCheckpoint() *MemCheckpoint {
+	return new(MemCheckpoint)
+}
+
+// Load implements Checkpoint.
+func (c *MemCheckpoint) Load() (string, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.id, nil
+}
+
+// Save implements Checkpoint.
+func (c *MemCheckpoint) Save(blockID string) error {
+	c.mux.Lock()
+	c.id = blockID
+	c.mux.Unlock()
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+
+// FileCheckpoint keeps block id in a file.
+type FileCheckpoint struct {
+	mux  sync.Mutex
+	file string
+}
+
+// NewFileCheckpoint returns a checkpoint in file.
+func NewFileCheckpoint(file string) *FileCheckpoint {
+	return &FileCheckpoint{file: file}
+}
+
+// Load implements Checkpoint. It returns empty string if file does not exist.
+func (c *FileCheckpoint) Load() (string, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	data, err := ioutil.ReadFile(c.file)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Save implements Checkpoint.
+// Block id is written into a temporary file and renamed to checkpoint file,
+// so file keeps the old block id if saving fails.
+func (c *FileCheckpoint) Save(blockID string) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(c.file), filepath.Base(c.file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(blockID + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.file)
+}
+
+// ----------------------------------------------------------------------------
+
+// blockID returns id of block commit event.
+func blockID(evt *events_pb2.Event) string {
+	for _, attr := range evt.Attributes {
+		if attr.Key == "block_id" {
+			return attr.Value
+		}
+	}
+	return ""
+}
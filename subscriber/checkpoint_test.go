@@ -0,0 +1,122 @@
+package subscriber
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/events_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/validator_pb2"
+)
+
+func TestFileCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cp := NewFileCheckpoint(filepath.Join(dir, "block"))
+	if id, err := cp.Load(); err != nil || id != "" {
+		t.Fatalf("empty checkpoint: %q, %v", id, err)
+	}
+
+	for _, want := range []string{"block1", "block2"} {
+		if err := cp.Save(want); err != nil {
+			t.Fatal(err)
+		}
+		if id, err := NewFileCheckpoint(filepath.Join(dir, "block")).Load(); err != nil || id != want {
+			t.Fatalf("checkpoint want %s, but %q, %v", want, id, err)
+		}
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("temporary files must be removed: %d files", len(files))
+	}
+}
+
+func blockEvents(t *testing.T, id string, types ...string) *validator_pb2.Message {
+	t.Helper()
+	list := &events_pb2.EventList{Events: []*events_pb2.Event{{
+		EventType:  EvtTypBlockCommit,
+		Attributes: []*events_pb2.Event_Attribute{{Key: "block_id", Value: id}, {Key: "block_num", Value: "1"}},
+	}}}
+	for _, x := range types {
+		list.Events = append(list.Events, &events_pb2.Event{EventType: x})
+	}
+
+	data, err := proto.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &validator_pb2.Message{MessageType: validator_pb2.Message_CLIENT_EVENTS, Content: data}
+}
+
+func TestRoutingCheckpoint(t *testing.T) {
+	cp := NewMemCheckpoint()
+	s := &Subscriber{handlers: make(map[string][]Handler), Checkpoint: cp}
+
+	s.HandleFunc("ok", func(string, *events_pb2.Event) bool { return true })
+	s.HandleFunc("stop", func(string, *events_pb2.Event) bool { return false })
+	s.HandleFunc("panic", func(string, *events_pb2.Event) bool { panic("boom") })
+
+	s.routing("1", blockEvents(t, "block1", "ok"))
+	if id, _ := cp.Load(); id != "block1" {
+		t.Fatalf("checkpoint want block1, but %q", id)
+	}
+
+	s.routing("1", blockEvents(t, "block2", "ok", "stop"))
+	s.routing("1", blockEvents(t, "block3", "panic"))
+	if id, _ := cp.Load(); id != "block1" {
+		t.Fatalf("unhandled blocks must not be saved: %q", id)
+	}
+
+	s.routing("1", blockEvents(t, "block4", "ok"))
+	if id, _ := cp.Load(); id != "block1" {
+		t.Fatalf("checkpoint must not pass unhandled blocks: %q", id)
+	}
+
+	s.HandleMessage("bad", func(string, *events_pb2.Event, proto.Message) bool { return true })
+	if decoded := s.handlers["bad"][0]; decoded("1", &events_pb2.Event{EventType: "bad"}) {
+		t.Error("events failing to decode must not be handled")
+	}
+
+	if ids := s.resume(nil); len(ids) != 1 || ids[0] != "block1" {
+		t.Fatalf("resume want block1, but %v", ids)
+	}
+	s.routing("1", blockEvents(t, "block2", "ok"))
+	if id, _ := cp.Load(); id != "block2" {
+		t.Fatalf("checkpoint must be saved after resubscribing: %q", id)
+	}
+
+	if s.subscribed(EvtTypBlockCommit) {
+		t.Error("block commit is not subscribed by user")
+	}
+
+	s.events = append(make([]*events_pb2.EventSubscription, 0, 2), &events_pb2.EventSubscription{EventType: "ok"})
+	if events := s.subscriptions(); len(events) != 2 || events[1].EventType != EvtTypBlockCommit {
+		t.Errorf("subscriptions: %v", events)
+	}
+	if s.events[:2][1] != nil {
+		t.Error("block commit must not be appended into events of subscriber")
+	}
+}
+
+func TestRecordBlockID(t *testing.T) {
+	s := &Subscriber{handlers: make(map[string][]Handler), RecordBlockID: true}
+	s.resume([]string{"block0"})
+
+	cp, ok := s.Checkpoint.(*FileCheckpoint)
+	if !ok || cp.file != blockIDFile {
+		t.Fatalf("RecordBlockID want file checkpoint %s, but %v", blockIDFile, s.Checkpoint)
+	}
+
+	mem := NewMemCheckpoint()
+	s = &Subscriber{handlers: make(map[string][]Handler), RecordBlockID: true, Checkpoint: mem}
+	if s.resume(nil); s.Checkpoint != mem {
+		t.Error("RecordBlockID must not replace checkpoint")
+	}
+}
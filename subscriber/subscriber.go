@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/dairaga/log"
+	"github.com/dairaga/sawtk/event"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/sawtooth-sdk-go/messaging"
@@ -17,16 +18,27 @@ import (
 	zmq "github.com/pebbe/zmq4"
 )
 
-// EvtTypBlockID sawtooth block id event
-//const EvtTypBlockID = "sawtooth/block-commit"
-
-// BlockIDFile save block id
-// const BlockIDFile = ".sub_block_id"
-
 // Handler is a sawtooth subscriber handler.
 // Return true if need to pass next handler, or return false.
 type Handler func(string, *events_pb2.Event) bool
 
+// MessageHandler is a sawtooth subscriber handler receiving data decoded by registered event type.
+// Return true if need to pass next handler, or return false.
+type MessageHandler func(string, *events_pb2.Event, proto.Message) bool
+
+// decoded returns a handler decoding data of event before calling h.
+// Events failing to decode are not handled, and stop next handlers.
+func decoded(h MessageHandler) Handler {
+	return func(id string, evt *events_pb2.Event) bool {
+		msg, err := event.Decode(evt)
+		if err != nil {
+			log.Errorf("decode %s: %v", evt.EventType, err)
+			return false
+		}
+		return h(id, evt, msg)
+	}
+}
+
 // Subscriber is a sawtooth event subscriber.
 type Subscriber struct {
 	endpoint string                          // validator endpoint.
@@ -37,8 +49,18 @@ type Subscriber struct {
 	handlers map[string][]Handler            // event handlers.
 	events   []*events_pb2.EventSubscription // subscribing events.
 	wait     time.Duration                   // duration of waiting for unsubscribing event.
+	failed   bool                            // some events are not handled, and checkpoint is not saved until resubscribing.
+
+	// Checkpoint records last block whose events are all handled, and
+	// subscriber resumes from the block if no last block ids are given.
+	// Once any event is not handled, checkpoint is not saved again until subscriber resubscribes
+	// from the checkpoint, ex: process restarts, so events after the checkpoint are sent again.
+	Checkpoint Checkpoint
 
-	RecordBlockID bool // flag to record last block id into file.
+	// RecordBlockID records last handled block id into file .sub_block_id if Checkpoint is nil.
+	//
+	// Deprecated: use Checkpoint with NewFileCheckpoint.
+	RecordBlockID bool
 
 	OnClose        func()
 	OnSubcribed    func(string, *client_event_pb2.ClientEventsSubscribeResponse)
@@ -76,6 +98,9 @@ func (s *Subscriber) routing(id string, msg *validator_pb2.Message) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Errorf("panic: %s %s %v", msg.CorrelationId, msg.MessageType.String(), r)
+			if msg.MessageType == validator_pb2.Message_CLIENT_EVENTS {
+				s.failed = true
+			}
 		}
 	}()
 
@@ -96,23 +121,42 @@ func (s *Subscriber) routing(id string, msg *validator_pb2.Message) {
 		evts := new(events_pb2.EventList)
 
 		if err := proto.Unmarshal(msg.Content, evts); err == nil {
+			handled := true
+			block := ""
 			for k, evt := range evts.Events {
 				log.Debugf("got event: %02d: %s", k, evt.EventType)
 
+				if evt.EventType == EvtTypBlockCommit {
+					block = blockID(evt)
+				}
+
 				handlers, ok := s.handlers[evt.EventType]
 
 				if ok && handlers != nil {
 					for i, h := range handlers {
 						if !h(id, evt) {
 							log.Errorf("%s handler (%d) stop", evt.EventType, i)
+							handled = false
 							break
 						}
 					}
-				} else {
+				} else if evt.EventType != EvtTypBlockCommit || s.Checkpoint == nil {
 					log.Warn("no handler for ", evt.EventType)
 				}
 			}
+
+			if !handled && !s.failed {
+				s.failed = true
+				log.Errorf("events of block %s are not handled, and checkpoint stops", block)
+			}
+
+			if s.Checkpoint != nil && !s.failed && block != "" {
+				if err := s.Checkpoint.Save(block); err != nil {
+					log.Errorf("save checkpoint %s: %v", block, err)
+				}
+			}
 		} else {
+			s.failed = true
 			log.Warn("event list unmarshal: ", err)
 		}
 
@@ -161,6 +205,16 @@ func (s *Subscriber) Subscribe(eventType string, h Handler, filters ...*events_p
 	s.handlers[eventType] = append(s.handlers[eventType], h)
 }
 
+// HandleMessage appends an handler for some registered event.
+func (s *Subscriber) HandleMessage(eventType string, h MessageHandler) {
+	s.HandleFunc(eventType, decoded(h))
+}
+
+// SubscribeMessage records an event subscription with filters and handler for some registered event.
+func (s *Subscriber) SubscribeMessage(eventType string, h MessageHandler, filters ...*events_pb2.EventFilter) {
+	s.Subscribe(eventType, decoded(h), filters...)
+}
+
 // Run subscriber run
 func (s *Subscriber) run() {
 
@@ -181,45 +235,64 @@ func (s *Subscriber) run() {
 	}()
 }
 
-/*
-// readLastBlockID returns last block id in file if exists.
-func (s *Subscriber) readLastBlockID() (string, error) {
-	dataBytes, err := ioutil.ReadFile(BlockIDFile)
-	if err != nil {
-		return "", err
+// subscribed returns event type is subscribed or not.
+func (s *Subscriber) subscribed(eventType string) bool {
+	for _, x := range s.events {
+		if x.EventType == eventType {
+			return true
+		}
 	}
+	return false
+}
 
-	var data []*events_pb2.Event_Attribute
+// subscriptions returns a copy of subscribing events, and block commit events if checkpoint is set.
+func (s *Subscriber) subscriptions() []*events_pb2.EventSubscription {
+	events := append([]*events_pb2.EventSubscription(nil), s.events...)
 
-	if err := json.Unmarshal(dataBytes, &data); err != nil {
-		return "", err
+	// checkpoint needs block commit events to know which block events belong to.
+	if s.Checkpoint != nil && !s.subscribed(EvtTypBlockCommit) {
+		events = append(events, &events_pb2.EventSubscription{EventType: EvtTypBlockCommit})
 	}
+	return events
+}
 
-	for _, x := range data {
-		if x.Key == "previous_block_id" {
-			return x.Value, nil
-		}
+// resume returns block ids which subscription starts after.
+// Block id in checkpoint is used if lastBlockIDs is empty, and checkpoint is saved again from then on.
+func (s *Subscriber) resume(lastBlockIDs []string) []string {
+	if s.Checkpoint == nil && s.RecordBlockID {
+		s.Checkpoint = NewFileCheckpoint(blockIDFile)
+	}
+
+	if s.Checkpoint == nil || len(lastBlockIDs) > 0 {
+		return lastBlockIDs
+	}
+
+	// events after checkpoint are sent again, so failed events are handled again.
+	s.failed = false
+
+	last, err := s.Checkpoint.Load()
+	if err != nil {
+		log.Warnf("load checkpoint: %v", err)
+		return nil
+	}
+	if last == "" {
+		return nil
 	}
 
-	return "", fmt.Errorf("previous block id not found")
+	log.Infof("resume from block %s", last)
+	return []string{last}
 }
-*/
 
 // sendSubscribe sends subscription after running.
+// Block id in checkpoint is used if lastBlockIDs is empty.
 func (s *Subscriber) sendSubscribe(lastBlockIDs []string) (string, error) {
 
-	/*if s.RecordBlockID {
-		s.Subscribe(EvtTypBlockID, s.handleBlock)
-		if prevID, err := s.readLastBlockID(); err != nil {
-			log.Warnf("read last block id: %v", err)
-		} else {
-			lastBlockIDs = append(lastBlockIDs, prevID)
-		}
-	}*/
+	lastBlockIDs = s.resume(lastBlockIDs)
+	events := s.subscriptions()
 
 	req := &client_event_pb2.ClientEventsSubscribeRequest{
 		LastKnownBlockIds: lastBlockIDs, // adds last block id
-		Subscriptions:     s.events,     // events to subscribe
+		Subscriptions:     events,       // events to subscribe
 	}
 
 	reqBytes, err := proto.Marshal(req)
@@ -237,33 +310,8 @@ func (s *Subscriber) sendSubscribe(lastBlockIDs []string) (string, error) {
 	return corID, nil
 }
 
-/*
-// handleBlock to record block data into file.
-func (s *Subscriber) handleBlock(id string, evt *events_pb2.Event) bool {
-
-	dataBytes, err := json.Marshal(evt.Attributes)
-	if err != nil {
-		log.Errorf("json unmarshal: %v", err)
-		return true // force to user's handler
-	}
-	//log.Debugf("record block id: %s", string(dataBytes))
-
-	if err := ioutil.WriteFile(BlockIDFile, dataBytes, 0644); err != nil {
-		log.Warnf("write block id: %v", err)
-	}
-
-	for i, attr := range evt.Attributes {
-		if attr.Key == "block_id" {
-			log.Debugf("%02d: key: %s, value: %s", i, attr.Key, attr.Value)
-			break
-		}
-	}
-
-	return true
-}
-*/
-
 // WaitForShutdown is to start a subscriber and wait for shutdown.
+// Events after lastBlockIDs or block in checkpoint are sent again.
 func (s *Subscriber) WaitForShutdown(lastBlockIDs ...string) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -0,0 +1,111 @@
+package tp
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+)
+
+// Budget limits resources used by a transaction. 0 means unlimited.
+type Budget struct {
+	Reads        int // distinct addresses read.
+	Writes       int // distinct addresses set or deleted.
+	BytesWritten int // bytes of data set.
+	Events       int // events added.
+	ReceiptBytes int // bytes of receipt data added.
+}
+
+func (b Budget) String() string {
+	return fmt.Sprintf(`{"reads": %d, "writes": %d, "bytes_written": %d, "events": %d, "receipt_bytes": %d}`,
+		b.Reads, b.Writes, b.BytesWritten, b.Events, b.ReceiptBytes)
+}
+
+// Usage is resources used by a transaction.
+type Usage Budget
+
+func (u Usage) String() string {
+	return Budget(u).String()
+}
+
+// over returns v is over limit or not.
+func over(v, limit int) bool {
+	return limit > 0 && v > limit
+}
+
+// Budget sets resource budget of each transaction.
+func (h *Handler) Budget(b Budget) {
+	h.budget = b
+}
+
+// ----------------------------------------------------------------------------
+
+// Usage returns resources used by transaction so far.
+// Writes and bytes written are counted on buffered changes.
+func (ctx *Context) Usage() Usage {
+	u := ctx.usage
+	u.Writes = len(ctx.changes)
+	u.BytesWritten = 0
+	for _, v := range ctx.changes {
+		u.BytesWritten += len(v)
+	}
+	return u
+}
+
+// useReads counts addresses not read before, and returns error if over budget.
+func (ctx *Context) useReads(keys []string) *processor.InvalidTransactionError {
+	var fresh []string
+	for _, k := range keys {
+		if !ctx.reads[k] {
+			fresh = append(fresh, k)
+		}
+	}
+
+	if n := ctx.usage.Reads + len(fresh); over(n, ctx.budget.Reads) {
+		return OverBudget.TxErrorf("reads %d over budget %d", n, ctx.budget.Reads)
+	}
+
+	for _, k := range fresh {
+		ctx.reads[k] = true
+	}
+	ctx.usage.Reads += len(fresh)
+	return nil
+}
+
+// useWrites returns error if changing pairs is over budget. Nil value means deleted.
+func (ctx *Context) useWrites(pairs map[string][]byte) *processor.InvalidTransactionError {
+	u := ctx.Usage()
+	for k, v := range pairs {
+		old, ok := ctx.changes[k]
+		if !ok {
+			u.Writes++
+		}
+		u.BytesWritten += len(v) - len(old)
+	}
+
+	if over(u.Writes, ctx.budget.Writes) {
+		return OverBudget.TxErrorf("writes %d over budget %d", u.Writes, ctx.budget.Writes)
+	}
+
+	if over(u.BytesWritten, ctx.budget.BytesWritten) {
+		return OverBudget.TxErrorf("bytes written %d over budget %d", u.BytesWritten, ctx.budget.BytesWritten)
+	}
+	return nil
+}
+
+// useEvent counts an event, and returns error if over budget.
+func (ctx *Context) useEvent() *processor.InvalidTransactionError {
+	if n := ctx.usage.Events + 1; over(n, ctx.budget.Events) {
+		return OverBudget.TxErrorf("events %d over budget %d", n, ctx.budget.Events)
+	}
+	ctx.usage.Events++
+	return nil
+}
+
+// useReceipt counts receipt data, and returns error if over budget.
+func (ctx *Context) useReceipt(size int) *processor.InvalidTransactionError {
+	if n := ctx.usage.ReceiptBytes + size; over(n, ctx.budget.ReceiptBytes) {
+		return OverBudget.TxErrorf("receipt bytes %d over budget %d", n, ctx.budget.ReceiptBytes)
+	}
+	ctx.usage.ReceiptBytes += size
+	return nil
+}
@@ -0,0 +1,58 @@
+package tp
+
+import (
+	"testing"
+
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/setting_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+func TestBudget(t *testing.T) {
+	state := newCountState()
+	state.data["b"] = []byte{0x0a, 0x00}
+	ctx := newContext(state, &transaction_pb2.TransactionHeader{}, 1)
+	ctx.budget = Budget{Reads: 2, Writes: 2, BytesWritten: 20, Events: 1, ReceiptBytes: 4}
+
+	assertOver := func(name string, err *processor.InvalidTransactionError) {
+		t.Helper()
+		if err == nil || ToErrCode(err.ExtendedData) != OverBudget {
+			t.Fatalf("%s want %v, but %v", name, OverBudget, err)
+		}
+	}
+
+	if _, err := ctx.Get("a", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ctx.Get("a", nil); err != nil {
+		t.Fatalf("read same address twice: %v", err)
+	}
+	if _, err := ctx.Get("b", nil); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ctx.Get("c", nil)
+	assertOver("reads", err)
+
+	if err := ctx.Set("a", &setting_pb2.Setting_Entry{Key: "k", Value: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	assertOver("bytes written", ctx.Set("b", &setting_pb2.Setting_Entry{Key: "a long key", Value: "a long value"}))
+
+	if _, err := ctx.Del([]string{"b"}); err != nil {
+		t.Fatal(err)
+	}
+	_, delErr := ctx.Del([]string{"c"})
+	assertOver("writes", delErr.(*processor.InvalidTransactionError))
+
+	if err := ctx.AddEvent("budget", nil); err != nil {
+		t.Fatal(err)
+	}
+	assertOver("events", ctx.AddEvent("budget", nil))
+
+	assertOver("receipt bytes", ctx.AddReceiptData(&setting_pb2.Setting_Entry{Key: "long"}))
+
+	u := ctx.Usage()
+	if u.Reads != 2 || u.Writes != 2 || u.Events != 1 || u.ReceiptBytes != 0 {
+		t.Fatalf("usage: %v", u)
+	}
+}
@@ -0,0 +1,175 @@
+package tp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dairaga/sawtk/client"
+	"github.com/dairaga/sawtk/ns"
+	"github.com/dairaga/sawtk/signing"
+	"github.com/dairaga/sawtk/tx"
+	"github.com/golang/protobuf/proto"
+)
+
+// FamilyClient submits SawTK requests of a family through sawtooth restful api.
+type FamilyClient struct {
+	*Family
+	namespace ns.Namespace
+	version   string
+	txb       *tx.Builder
+	bb        *tx.BatchBuilder
+	cli       *client.Client
+	wait      int
+	encoding  string // encoding of payload in TPRequest.
+	whole     bool   // request is encoded entirely in encoding.
+}
+
+// NewFamilyClient returns a client of family.
+// signer signs both transactions and batches, and the first version of family is used.
+func NewFamilyClient(family *Family, namespace ns.Namespace, signer *signing.Signer, cli *client.Client) *FamilyClient {
+	return &FamilyClient{
+		Family:    family,
+		namespace: namespace,
+		version:   family.versions[0],
+		txb:       tx.NewBuilder(signer.GetPublicKey().AsHex(), signer),
+		bb:        tx.NewBatchBuilder(signer),
+		cli:       cli,
+		wait:      5,
+	}
+}
+
+func (fc *FamilyClient) String() string {
+	return fmt.Sprintf(`{"family": %s, "namespace": "%s", "version": "%s", "client": %s}`, fc.Family.String(), fc.namespace.Prefix(), fc.version, fc.cli.String())
+}
+
+// Version sets family version of transactions.
+func (fc *FamilyClient) Version(v string) *FamilyClient {
+	for _, x := range fc.versions {
+		if x == v {
+			fc.version = v
+			return fc
+		}
+	}
+
+	panic(fmt.Sprintf("family %s does not support version %s", fc.name, v))
+}
+
+// Encoding sets encoding of payload in TPRequest.
+func (fc *FamilyClient) Encoding(encoding string) *FamilyClient {
+	if _, err := CodecOf(encoding); err != nil {
+		panic(err)
+	}
+	fc.encoding = encoding
+	fc.whole = false
+	return fc
+}
+
+// VersionEncoding sets requests are encoded entirely in encoding.
+// Handler must set the same encoding of the family version by Handler.VersionEncoding.
+func (fc *FamilyClient) VersionEncoding(encoding string) *FamilyClient {
+	fc.Encoding(encoding)
+	fc.whole = true
+	return fc
+}
+
+// BatchSigner sets another signer to sign batches.
+func (fc *FamilyClient) BatchSigner(signer *signing.Signer) *FamilyClient {
+	fc.bb = tx.NewBatchBuilder(signer)
+	fc.txb = tx.NewBuilder(signer.GetPublicKey().AsHex(), fc.txb.Signer())
+	return fc
+}
+
+// Wait sets seconds of waiting for batch status in each query.
+func (fc *FamilyClient) Wait(seconds int) *FamilyClient {
+	fc.wait = seconds
+	return fc
+}
+
+// Namespace returns namespace of family.
+func (fc *FamilyClient) Namespace() ns.Namespace {
+	return fc.namespace
+}
+
+// Address returns address of key in family namespace.
+func (fc *FamilyClient) Address(key string) string {
+	return fc.namespace.MakeAddress(key)
+}
+
+// Client returns internal sawtooth restful api client.
+func (fc *FamilyClient) Client() *client.Client {
+	return fc.cli
+}
+
+// Data returns transaction data with command and message.
+// inputs and outputs must be addresses or prefixes in family namespace.
+func (fc *FamilyClient) Data(cmd int32, msg proto.Message, inputs, outputs []string) (*tx.Data, error) {
+	set := tx.NewAddressSet(fc.namespace).Input(inputs...).Output(outputs...)
+
+	if fc.whole {
+		payload, err := EncodeRequest(fc.encoding, cmd, msg)
+		if err != nil {
+			return nil, err
+		}
+		return tx.NewRawWithAddressSet(fc.name, fc.version, payload, set)
+	}
+
+	req, err := NewTPRequestEncoding(fc.encoding, cmd, msg)
+	if err != nil {
+		return nil, err
+	}
+	return tx.NewWithAddressSet(fc.name, fc.version, req, set)
+}
+
+// Submit sends a command and message to chain, and waits until the batch is committed or invalid.
+// It returns the batch status, and an error if the batch is not committed.
+func (fc *FamilyClient) Submit(ctx context.Context, cmd int32, msg proto.Message, inputs, outputs []string) (*client.BatchStatus, error) {
+	data, err := fc.Data(cmd, msg, inputs, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := data.ToBatches(fc.bb, fc.txb)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := fc.cli.SubmitBatches(list)
+	if err != nil {
+		return nil, err
+	}
+
+	return fc.waitFor(ctx, fmt.Sprintf("%s&wait=%d", link.Link, fc.wait))
+}
+
+// waitFor queries batch status until it is not pending or context is done.
+func (fc *FamilyClient) waitFor(ctx context.Context, url string) (*client.BatchStatus, error) {
+	for {
+		bss, err := fc.cli.BatchStatusesWithURL(url)
+		if err != nil && !client.IsTimeError(err) {
+			return nil, err
+		}
+
+		if err == nil {
+			if len(bss.Data) <= 0 {
+				return nil, errors.New("batch status not found")
+			}
+
+			status := bss.Data[0]
+			if status.IsCommitted() {
+				return &status, nil
+			}
+
+			if status.IsInvalid() {
+				return &status, bss
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package tp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dairaga/sawtk/client"
+	"github.com/dairaga/sawtk/ns"
+	"github.com/dairaga/sawtk/signing"
+)
+
+func TestFamilyClientSubmit(t *testing.T) {
+	status := client.BSInvalid
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/batches":
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprintf(w, `{"link": "http://%s/batch_statuses?id=abc"}`, r.Host)
+		case "/batch_statuses":
+			fmt.Fprintf(w, `{"data": [{"id": "abc", "status": %q}]}`, status)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	myns := ns.New("fctest")
+	fc := NewFamilyClient(NewFamily("fctest", []string{"1.0"}, []string{myns.Prefix()}), myns, signing.GenerateSignerFromCode("fc"), client.New(srv.URL, time.Second))
+
+	addr := fc.Address("a")
+	if _, err := fc.Submit(context.Background(), 1, nil, []string{addr}, []string{addr}); err == nil {
+		t.Fatal("invalid batch must return error")
+	}
+
+	status = client.BSCommitted
+	bs, err := fc.Submit(context.Background(), 1, nil, []string{addr}, []string{addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bs.IsCommitted() {
+		t.Errorf("batch status want %s, but %s", client.BSCommitted, bs.Status)
+	}
+
+	if _, err := fc.Submit(context.Background(), 1, nil, []string{ns.New("other").MakeAddress("a")}, nil); err == nil {
+		t.Error("address not in family namespace must fail")
+	}
+}
@@ -0,0 +1,385 @@
+package tp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+)
+
+// Payload encodings.
+const (
+	EncodingProtobuf = "protobuf"
+	EncodingJSON     = "json"
+	EncodingCBOR     = "cbor"
+)
+
+// Codec encodes payload messages and requests.
+type Codec interface {
+	// Name returns name of encoding.
+	Name() string
+
+	// Marshal encodes a payload message.
+	Marshal(pb proto.Message) ([]byte, error)
+
+	// Unmarshal decodes a payload message.
+	Unmarshal(data []byte, pb proto.Message) error
+
+	// MarshalRequest encodes a request with command and payload message.
+	MarshalRequest(cmd int32, pb proto.Message) ([]byte, error)
+
+	// UnmarshalRequest decodes a request. Payload of result is encoded by the codec.
+	UnmarshalRequest(data []byte) (*TPRequest, error)
+}
+
+var (
+	codecMutex sync.RWMutex
+	codecs     = map[string]Codec{
+		EncodingProtobuf: protobufCodec{},
+		EncodingJSON:     jsonCodec{},
+		EncodingCBOR:     cborCodec{},
+	}
+)
+
+// RegisterCodec registers a codec, and replaces codec with the same name.
+func RegisterCodec(c Codec) {
+	codecMutex.Lock()
+	defer codecMutex.Unlock()
+
+	codecs[c.Name()] = c
+}
+
+// CodecOf returns codec of encoding. Empty encoding means protobuf.
+func CodecOf(encoding string) (Codec, error) {
+	if encoding == "" {
+		encoding = EncodingProtobuf
+	}
+
+	codecMutex.RLock()
+	defer codecMutex.RUnlock()
+
+	c, ok := codecs[encoding]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding: %s", encoding)
+	}
+	return c, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// protobufCodec encodes requests in TPRequest.
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string {
+	return EncodingProtobuf
+}
+
+func (protobufCodec) Marshal(pb proto.Message) ([]byte, error) {
+	return proto.Marshal(pb)
+}
+
+func (protobufCodec) Unmarshal(data []byte, pb proto.Message) error {
+	return proto.Unmarshal(data, pb)
+}
+
+func (protobufCodec) MarshalRequest(cmd int32, pb proto.Message) ([]byte, error) {
+	return NewTPRequestBytes(cmd, pb)
+}
+
+func (protobufCodec) UnmarshalRequest(data []byte) (*TPRequest, error) {
+	req := new(TPRequest)
+	if err := proto.Unmarshal(data, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// jsonMarshaler encodes messages in proto3 JSON with field names in proto files.
+var jsonMarshaler = &jsonpb.Marshaler{OrigName: true}
+
+// canonicalJSON encodes v in canonical JSON: keys are sorted, without insignificant whitespace and HTML escaping,
+// so clients in other languages can build the same bytes.
+func canonicalJSON(v interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// jsonRequest is a request in JSON or CBOR.
+type jsonRequest struct {
+	Cmd     int32       `json:"cmd" cbor:"cmd"`
+	Payload interface{} `json:"payload,omitempty" cbor:"payload,omitempty"`
+}
+
+// jsonCodec encodes messages in canonical proto3 JSON, and requests in JSON: {"cmd": 1, "payload": {...}, "requests": [...]}.
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return EncodingJSON
+}
+
+func (jsonCodec) Marshal(pb proto.Message) ([]byte, error) {
+	s, err := jsonMarshaler.MarshalToString(pb)
+	if err != nil {
+		return nil, err
+	}
+
+	// numbers are kept in text to avoid changes by float64.
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return canonicalJSON(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, pb proto.Message) error {
+	return jsonpb.Unmarshal(bytes.NewReader(data), pb)
+}
+
+func (c jsonCodec) MarshalRequest(cmd int32, pb proto.Message) ([]byte, error) {
+	req := jsonRequest{Cmd: cmd}
+	if pb != nil {
+		data, err := c.Marshal(pb)
+		if err != nil {
+			return nil, err
+		}
+		req.Payload = json.RawMessage(data)
+	}
+	return canonicalJSON(req)
+}
+
+func (c jsonCodec) UnmarshalRequest(data []byte) (*TPRequest, error) {
+	var req struct {
+		Cmd      int32             `json:"cmd"`
+		Payload  json.RawMessage   `json:"payload"`
+		Requests []json.RawMessage `json:"requests"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, err
+	}
+
+	ret := &TPRequest{Cmd: req.Cmd, Payload: req.Payload, Encoding: EncodingJSON}
+	for i, x := range req.Requests {
+		r, err := c.UnmarshalRequest(x)
+		if err != nil {
+			return nil, fmt.Errorf("requests[%d]: %v", i, err)
+		}
+		ret.Requests = append(ret.Requests, r)
+	}
+	return ret, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// cborOptions encodes in canonical CBOR.
+var cborOptions = cbor.CanonicalEncOptions()
+
+// cborCodec encodes requests in CBOR with the same structure of JSON.
+type cborCodec struct{}
+
+func (cborCodec) Name() string {
+	return EncodingCBOR
+}
+
+// generic returns value of message in proto3 JSON structure.
+func generic(pb proto.Message) (interface{}, error) {
+	s, err := jsonMarshaler.MarshalToString(pb)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, err
+	}
+	return integral(v), nil
+}
+
+// integral converts whole numbers in JSON value to integers for compact CBOR.
+func integral(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, y := range x {
+			x[k] = integral(y)
+		}
+	case []interface{}:
+		for i, y := range x {
+			x[i] = integral(y)
+		}
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1<<53 {
+			return int64(x)
+		}
+	}
+	return v
+}
+
+// stringKeys converts maps decoded from CBOR to maps with string keys for JSON.
+func stringKeys(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, y := range x {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("cbor map key must be string, but %T", k)
+			}
+
+			val, err := stringKeys(y)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = val
+		}
+		return m, nil
+	case []interface{}:
+		for i, y := range x {
+			val, err := stringKeys(y)
+			if err != nil {
+				return nil, err
+			}
+			x[i] = val
+		}
+	}
+	return v, nil
+}
+
+func (cborCodec) Marshal(pb proto.Message) ([]byte, error) {
+	v, err := generic(pb)
+	if err != nil {
+		return nil, err
+	}
+	return cbor.Marshal(v, cborOptions)
+}
+
+func (cborCodec) Unmarshal(data []byte, pb proto.Message) error {
+	var v interface{}
+	if err := cbor.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	v, err := stringKeys(v)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return jsonpb.Unmarshal(bytes.NewReader(tmp), pb)
+}
+
+func (cborCodec) MarshalRequest(cmd int32, pb proto.Message) ([]byte, error) {
+	req := jsonRequest{Cmd: cmd}
+	if pb != nil {
+		v, err := generic(pb)
+		if err != nil {
+			return nil, err
+		}
+		req.Payload = v
+	}
+	return cbor.Marshal(req, cborOptions)
+}
+
+func (c cborCodec) UnmarshalRequest(data []byte) (*TPRequest, error) {
+	var req struct {
+		Cmd      int32             `cbor:"cmd"`
+		Payload  interface{}       `cbor:"payload"`
+		Requests []cbor.RawMessage `cbor:"requests"`
+	}
+	if err := cbor.Unmarshal(data, &req); err != nil {
+		return nil, err
+	}
+
+	ret := &TPRequest{Cmd: req.Cmd, Encoding: EncodingCBOR}
+	if req.Payload != nil {
+		payload, err := cbor.Marshal(req.Payload, cborOptions)
+		if err != nil {
+			return nil, err
+		}
+		ret.Payload = payload
+	}
+
+	for i, x := range req.Requests {
+		r, err := c.UnmarshalRequest(x)
+		if err != nil {
+			return nil, fmt.Errorf("requests[%d]: %v", i, err)
+		}
+		ret.Requests = append(ret.Requests, r)
+	}
+	return ret, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// Unmarshal decodes payload into pb with encoding of request.
+func (r *TPRequest) Unmarshal(pb proto.Message) error {
+	c, err := CodecOf(r.Encoding)
+	if err != nil {
+		return err
+	}
+	return c.Unmarshal(r.Payload, pb)
+}
+
+// NewTPRequestEncoding returns a SawTK TPRequest with payload in encoding.
+func NewTPRequestEncoding(encoding string, cmd int32, data proto.Message) (*TPRequest, error) {
+	c, err := CodecOf(encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &TPRequest{Cmd: cmd}
+	if c.Name() != EncodingProtobuf {
+		req.Encoding = c.Name()
+	}
+
+	if data != nil {
+		if req.Payload, err = c.Marshal(data); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// EncodeRequest returns request with command and data encoded entirely in encoding.
+// It is for family versions with encoding set by Handler.VersionEncoding.
+func EncodeRequest(encoding string, cmd int32, data proto.Message) ([]byte, error) {
+	c, err := CodecOf(encoding)
+	if err != nil {
+		return nil, err
+	}
+	return c.MarshalRequest(cmd, data)
+}
+
+// VersionEncoding sets requests of family version are encoded entirely in encoding.
+// Requests of other versions are TPRequest in protobuf, and their payloads are in encoding of TPRequest.
+func (h *Handler) VersionEncoding(version, encoding string) {
+	if _, err := CodecOf(encoding); err != nil {
+		panic(err)
+	}
+	h.encodings[version] = encoding
+}
+
+// decodeRequest returns request in payload of family version.
+func (h *Handler) decodeRequest(version string, payload []byte) (*TPRequest, error) {
+	c, err := CodecOf(h.encodings[version])
+	if err != nil {
+		return nil, err
+	}
+	return c.UnmarshalRequest(payload)
+}
@@ -0,0 +1,134 @@
+package tp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fxamacker/cbor"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/processor_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/setting_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+func TestCodecs(t *testing.T) {
+	want := &setting_pb2.Setting_Entry{Key: "a", Value: "1"}
+
+	for _, enc := range []string{EncodingProtobuf, EncodingJSON, EncodingCBOR} {
+		req, err := NewTPRequestEncoding(enc, 3, want)
+		if err != nil {
+			t.Fatal(enc, err)
+		}
+
+		got := new(setting_pb2.Setting_Entry)
+		if err := req.Unmarshal(got); err != nil || !proto.Equal(got, want) {
+			t.Fatalf("%s envelope want %v, but %v %v", enc, want, got, err)
+		}
+
+		data, err := EncodeRequest(enc, 3, want)
+		if err != nil {
+			t.Fatal(enc, err)
+		}
+
+		c, _ := CodecOf(enc)
+		req, err = c.UnmarshalRequest(data)
+		if err != nil || req.Cmd != 3 {
+			t.Fatalf("%s request: %v %v", enc, req, err)
+		}
+
+		got.Reset()
+		if err := req.Unmarshal(got); err != nil || !proto.Equal(got, want) {
+			t.Fatalf("%s request want %v, but %v %v", enc, want, got, err)
+		}
+	}
+
+	if data, _ := EncodeRequest(EncodingJSON, 3, want); string(data) != `{"cmd":3,"payload":{"key":"a","value":"1"}}` {
+		t.Fatalf("json request: %s", data)
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	c, _ := CodecOf(EncodingJSON)
+	data, err := c.Marshal(&TPRequest{Cmd: 1, Payload: []byte("<a>"), Encoding: "<&>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"cmd":1,"encoding":"<&>","payload":"PGE+"}`; string(data) != want {
+		t.Fatalf("canonical json want %s, but %s", want, data)
+	}
+
+	data, err = c.MarshalRequest(2, &TPRequest{Encoding: "x", Cmd: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"cmd":2,"payload":{"cmd":3,"encoding":"x"}}`; string(data) != want {
+		t.Fatalf("canonical request want %s, but %s", want, data)
+	}
+}
+
+func TestCodecMultiRequests(t *testing.T) {
+	nested := map[string]interface{}{
+		"cmd": 0,
+		"requests": []interface{}{
+			map[string]interface{}{"cmd": 1, "payload": map[string]interface{}{"key": "a", "value": "1"}},
+			map[string]interface{}{"cmd": 2, "requests": []interface{}{map[string]interface{}{"cmd": 3}}},
+		},
+	}
+	jsonData, _ := json.Marshal(nested)
+	cborData, _ := cbor.Marshal(nested, cborOptions)
+
+	for enc, data := range map[string][]byte{EncodingJSON: jsonData, EncodingCBOR: cborData} {
+		c, _ := CodecOf(enc)
+		req, err := c.UnmarshalRequest(data)
+		if err != nil {
+			t.Fatal(enc, err)
+		}
+		if len(req.Requests) != 2 || req.Requests[1].Cmd != 2 || len(req.Requests[1].Requests) != 1 || req.Requests[1].Requests[0].Cmd != 3 {
+			t.Fatalf("%s requests: %v", enc, req)
+		}
+
+		got := new(setting_pb2.Setting_Entry)
+		if err := req.Requests[0].Unmarshal(got); err != nil || got.Value != "1" {
+			t.Fatalf("%s payload of requests[0]: %v %v", enc, got, err)
+		}
+	}
+
+	c, _ := CodecOf(EncodingJSON)
+	if _, err := c.UnmarshalRequest([]byte(`{"cmd":0,"requests":[{"cmd":"x"}]}`)); err == nil {
+		t.Error("bad nested request must fail")
+	}
+}
+
+func TestHandlerEncodings(t *testing.T) {
+	h := NewHandler(NewFamily("enctest", []string{"1.0", "2.0"}, []string{"000000"}))
+	h.VersionEncoding("2.0", EncodingCBOR)
+
+	var got []string
+	h.Add(1, func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+		e := new(setting_pb2.Setting_Entry)
+		if err := unmarshal(req, e); err != nil {
+			return err
+		}
+		got = append(got, e.Value)
+		return nil
+	})
+
+	req, _ := NewTPRequestEncoding(EncodingJSON, 1, &setting_pb2.Setting_Entry{Key: "k", Value: "json"})
+	payload, _ := req.ToBytes()
+	cbor, _ := EncodeRequest(EncodingCBOR, 1, &setting_pb2.Setting_Entry{Key: "k", Value: "cbor"})
+
+	for version, data := range map[string][]byte{"1.0": payload, "2.0": cbor} {
+		err := h.Handle(&processor_pb2.TpProcessRequest{
+			Header:  &transaction_pb2.TransactionHeader{FamilyVersion: version},
+			Payload: data,
+		}, newCountState())
+		if err != nil {
+			t.Fatal(version, err)
+		}
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("handled: %v", got)
+	}
+}
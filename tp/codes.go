@@ -0,0 +1,229 @@
+package tp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/dairaga/sawtk/client"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+)
+
+// CodeInfo describes a registered error code.
+type CodeInfo struct {
+	Code     ErrCode
+	Name     string
+	Category int    // HTTP-style status code, ex: 400, 404, 409, 500.
+	Template string // fmt format of message.
+}
+
+func (ci CodeInfo) String() string {
+	return fmt.Sprintf(`{"code": %d, "name": "%s", "category": %d, "template": %q}`, ci.Code, ci.Name, ci.Category, ci.Template)
+}
+
+var (
+	codeMutex sync.RWMutex
+	codes     = make(map[ErrCode]CodeInfo)
+	builtins  = make(map[ErrCode]bool)
+)
+
+func init() {
+	for _, ci := range []CodeInfo{
+		{Undeclared, "Undeclared", http.StatusForbidden, "address %s is not declared"},
+		{OverBudget, "OverBudget", http.StatusRequestEntityTooLarge, "%s over budget"},
+		{Migrate, "Migrate", http.StatusInternalServerError, "migrate state: %v"},
+		{Forbidden, "Forbidden", http.StatusForbidden, "signer %s is not allowed"},
+		{Internal, "Internal", http.StatusInternalServerError, "internal error: %v"},
+		{Wallet, "Wallet", http.StatusBadRequest, "generate wallet: %v"},
+		{BadParameters, "BadParameters", http.StatusBadRequest, "bad parameters: %v"},
+		{LenNotMatch, "LenNotMatch", http.StatusBadRequest, "length not match: %v"},
+		{Conflict, "Conflict", http.StatusConflict, "%s exists"},
+		{NotFound, "NotFound", http.StatusNotFound, "%s not found"},
+		{GetState, "GetState", http.StatusInternalServerError, "get state: %v"},
+		{SetState, "SetState", http.StatusInternalServerError, "set state: %v"},
+		{Events, "Events", http.StatusInternalServerError, "add event: %v"},
+		{ReceiptData, "ReceiptData", http.StatusInternalServerError, "add receipt data: %v"},
+		{Unmarshal, "Unmarshal", http.StatusBadRequest, "unmarshal: %v"},
+		{Marshal, "Marshal", http.StatusInternalServerError, "marshal: %v"},
+		{UnknownCmd, "UnknownCmd", http.StatusBadRequest, "unknown command: %d"},
+	} {
+		codes[ci.Code] = ci
+		builtins[ci.Code] = true
+	}
+}
+
+// RegisterCode registers an error code of family.
+// It returns error if code or name is registered.
+func RegisterCode(code ErrCode, name string, category int, template string) error {
+	if name == "" {
+		return fmt.Errorf("error code %d must have a name", code)
+	}
+
+	codeMutex.Lock()
+	defer codeMutex.Unlock()
+
+	if ci, ok := codes[code]; ok {
+		if builtins[code] {
+			return fmt.Errorf("error code %d (%s) collides with built-in %s", code, name, ci.Name)
+		}
+		return fmt.Errorf("error code %d (%s) is registered as %s", code, name, ci.Name)
+	}
+
+	for _, ci := range codes {
+		if ci.Name == name {
+			return fmt.Errorf("error name %s is registered with code %d", name, ci.Code)
+		}
+	}
+
+	codes[code] = CodeInfo{
+		Code:     code,
+		Name:     name,
+		Category: category,
+		Template: template,
+	}
+	return nil
+}
+
+// MustRegisterCode registers an error code of family.
+// Panic if registering failure.
+func MustRegisterCode(code ErrCode, name string, category int, template string) ErrCode {
+	if err := RegisterCode(code, name, category, template); err != nil {
+		panic(err)
+	}
+	return code
+}
+
+// unregisterCodes removes registered codes of family. Built-in codes are kept.
+// It is for tests registering the same codes in each run.
+func unregisterCodes(list ...ErrCode) {
+	codeMutex.Lock()
+	defer codeMutex.Unlock()
+
+	for _, x := range list {
+		if !builtins[x] {
+			delete(codes, x)
+		}
+	}
+}
+
+// LookupCode returns registered information of code.
+func LookupCode(code ErrCode) (CodeInfo, bool) {
+	codeMutex.RLock()
+	defer codeMutex.RUnlock()
+
+	ci, ok := codes[code]
+	return ci, ok
+}
+
+// Codes returns registered codes in order.
+func Codes() []CodeInfo {
+	codeMutex.RLock()
+	defer codeMutex.RUnlock()
+
+	ret := make([]CodeInfo, 0, len(codes))
+	for _, ci := range codes {
+		ret = append(ret, ci)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Code < ret[j].Code })
+	return ret
+}
+
+// Name returns registered name of code, or empty string if not registered.
+func (c ErrCode) Name() string {
+	ci, _ := LookupCode(c)
+	return ci.Name
+}
+
+// TxErrort returns a InvalidTransactionError with message made from registered template.
+func (c ErrCode) TxErrort(a ...interface{}) *processor.InvalidTransactionError {
+	ci, ok := LookupCode(c)
+	if !ok || ci.Template == "" {
+		return c.TxErrorf("%s", fmt.Sprint(a...))
+	}
+	return c.TxErrorf(ci.Template, a...)
+}
+
+// ----------------------------------------------------------------------------
+
+// CodeError is an invalid transaction error decoded by registered codes.
+type CodeError struct {
+	ID       string // transaction id.
+	Code     ErrCode
+	Name     string // empty if code is not registered.
+	Category int    // 500 if code is not registered.
+	Msg      string
+	Index    int // index of failing command in multi-command request, -1 if not.
+}
+
+func (e *CodeError) Error() string {
+	return fmt.Sprintf(`{"id": "%s", "code": %d, "name": "%s", "category": %d, "message": %q}`, e.ID, e.Code, e.Name, e.Category, e.Msg)
+}
+
+// newCodeError returns error of message and extended data.
+func newCodeError(id, msg string, extended []byte) *CodeError {
+	e := &CodeError{
+		ID:       id,
+		Category: http.StatusInternalServerError,
+		Msg:      msg,
+		Index:    -1,
+	}
+
+	if i, ok := FailedIndex(msg); ok {
+		e.Index = i
+	}
+
+	if len(extended) != 4 {
+		return e
+	}
+
+	e.Code = ToErrCode(extended)
+	if ci, ok := LookupCode(e.Code); ok {
+		e.Name = ci.Name
+		e.Category = ci.Category
+	}
+	return e
+}
+
+// DecodeInvalid returns error of invalid transaction in batch status.
+func DecodeInvalid(it client.InvalidTransaction) *CodeError {
+	extended, err := base64.StdEncoding.DecodeString(it.ExtendedData)
+	if err != nil {
+		extended = nil
+	}
+	return newCodeError(it.ID, it.Message, extended)
+}
+
+// InvalidErrors returns errors of all invalid transactions in batch statuses.
+func InvalidErrors(bss *client.BatchStatuses) []*CodeError {
+	var ret []*CodeError
+	for _, bs := range bss.Invalid() {
+		for _, it := range bs.InvalidTransactions {
+			ret = append(ret, DecodeInvalid(it))
+		}
+	}
+	return ret
+}
+
+// AsCodeError returns CodeError in err.
+// err can be CodeError, InvalidTransactionError, or BatchStatuses having invalid transactions.
+func AsCodeError(err error) (*CodeError, bool) {
+	switch v := err.(type) {
+	case *CodeError:
+		return v, v != nil
+	case *processor.InvalidTransactionError:
+		if v == nil {
+			return nil, false
+		}
+		return newCodeError("", v.Msg, v.ExtendedData), true
+	case *client.BatchStatuses:
+		if v == nil {
+			return nil, false
+		}
+		if errs := InvalidErrors(v); len(errs) > 0 {
+			return errs[0], true
+		}
+	}
+	return nil, false
+}
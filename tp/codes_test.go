@@ -0,0 +1,58 @@
+package tp
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/dairaga/sawtk/client"
+)
+
+func TestRegisterCode(t *testing.T) {
+	if err := RegisterCode(NotFound, "MyNotFound", http.StatusNotFound, "%s"); err == nil {
+		t.Fatal("register built-in code must fail")
+	}
+
+	const code, name = ErrCode(1000), "Insufficient"
+	defer unregisterCodes(code)
+
+	if err := RegisterCode(code, name, http.StatusConflict, "balance %d is less than %d"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RegisterCode(code+1, name, http.StatusConflict, ""); err == nil {
+		t.Fatal("register name twice must fail")
+	}
+
+	if err := RegisterCode(code, name+"x", http.StatusConflict, ""); err == nil {
+		t.Fatal("register code twice must fail")
+	}
+
+	if got := code.Name(); got != name {
+		t.Fatalf("name want %s, but %s", name, got)
+	}
+
+	txerr := code.TxErrort(1, 2)
+	if txerr.Msg != "balance 1 is less than 2" {
+		t.Fatalf("message: %s", txerr.Msg)
+	}
+
+	bss := &client.BatchStatuses{Data: []client.BatchStatus{{
+		ID:     "b1",
+		Status: client.BSInvalid,
+		InvalidTransactions: []client.InvalidTransaction{{
+			ID:           "t1",
+			Message:      txerr.Msg,
+			ExtendedData: base64.StdEncoding.EncodeToString(txerr.ExtendedData),
+		}},
+	}}}
+
+	e, ok := AsCodeError(bss)
+	if !ok || e.ID != "t1" || e.Code != code || e.Name != name || e.Category != http.StatusConflict {
+		t.Fatalf("decode invalid: %v", e)
+	}
+
+	if e, ok := AsCodeError(Unmarshal.TxErrorf("bad")); !ok || e.Name != "Unmarshal" || e.Category != http.StatusBadRequest {
+		t.Fatalf("decode tx error: %v", e)
+	}
+}
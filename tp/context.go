@@ -2,10 +2,13 @@ package tp
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/dairaga/sawtk/event"
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/sawtooth-sdk-go/processor"
 	"github.com/hyperledger/sawtooth-sdk-go/protobuf/setting_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
 )
 
 func mapKeys(data map[string]proto.Message) []string {
@@ -36,15 +39,52 @@ func Attribute(key, value string) processor.Attribute {
 
 // ----------------------------------------------------------------------------
 
+// State is the state access of a transaction.
+// It is implemented by *processor.Context, and by fakes in tests.
+type State interface {
+	GetState(addresses []string) (map[string][]byte, error)
+	SetState(pairs map[string][]byte) ([]string, error)
+	DeleteState(addresses []string) ([]string, error)
+	AddReceiptData(data []byte) error
+	AddEvent(typ string, attributes []processor.Attribute, data []byte) error
+}
+
 // Context wraps sawtooth processor context.
+// Writes and deletes are buffered and flushed after handler succeeds,
+// and reads are served from buffer and cache first.
 type Context struct {
-	ref    *processor.Context
-	cmd    int32
-	signer string
+	ref     State
+	header  *transaction_pb2.TransactionHeader
+	cmd     int32
+	signer  string
+	version string
+	schemas map[string]*Schema
+	cache   map[string][]byte // states read from validator, nil means not found.
+	changes map[string][]byte // states changed, nil means deleted.
+
+	settings   map[string]settingEntry // decoded settings.
+	namespaces map[string]string       // family names of namespace prefixes.
+
+	budget Budget
+	usage  Usage
+	reads  map[string]bool // addresses read.
+}
+
+func newContext(ref State, header *transaction_pb2.TransactionHeader, cmd int32) *Context {
+	return &Context{
+		ref:     ref,
+		header:  header,
+		cmd:     cmd,
+		signer:  header.SignerPublicKey,
+		version: header.FamilyVersion,
+		cache:   make(map[string][]byte),
+		changes: make(map[string][]byte),
+		reads:   make(map[string]bool),
+	}
 }
 
 func (ctx *Context) String() string {
-	return fmt.Sprintf(`{cmd: %d, signer: "%s"}`, ctx.Cmd(), ctx.signer)
+	return fmt.Sprintf(`{cmd: %d, signer: "%s", version: "%s"}`, ctx.Cmd(), ctx.signer, ctx.version)
 }
 
 // Cmd returns current transaction command.
@@ -52,23 +92,84 @@ func (ctx *Context) Cmd() int32 {
 	return ctx.cmd
 }
 
+// Version returns family version in transaction header.
+func (ctx *Context) Version() string {
+	return ctx.version
+}
+
+// Header returns transaction header.
+func (ctx *Context) Header() *transaction_pb2.TransactionHeader {
+	return ctx.header
+}
+
 // SignerPublicKey returns transaction signer public key.
 func (ctx *Context) SignerPublicKey() string {
 	return ctx.signer
 }
 
 // Context returns internal *process.Context.
+// It returns nil if the state is not from validator.
 func (ctx *Context) Context() *processor.Context {
+	ref, _ := ctx.ref.(*processor.Context)
+	return ref
+}
+
+// State returns internal state.
+func (ctx *Context) State() State {
 	return ctx.ref
 }
 
 // ----------------------------------------------------------------------------
 
+// getState returns states from buffer, cache or validator.
+func (ctx *Context) getState(keys []string) (map[string][]byte, error) {
+	result := make(map[string][]byte)
+	var missing []string
+
+	for _, k := range keys {
+		b, ok := ctx.changes[k]
+		if !ok {
+			b, ok = ctx.cache[k]
+		}
+
+		if !ok {
+			missing = append(missing, k)
+		} else if len(b) > 0 {
+			result[k] = b
+		}
+	}
+
+	if len(missing) <= 0 {
+		return result, nil
+	}
+
+	fetched, err := ctx.ref.GetState(missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range missing {
+		b := fetched[k]
+		ctx.cache[k] = b
+		if len(b) > 0 {
+			result[k] = b
+		}
+	}
+
+	return result, nil
+}
+
 // GetAll returns states with multiple addresses.
 func (ctx *Context) GetAll(data map[string]proto.Message) *processor.InvalidTransactionError {
 	keys := mapKeys(data)
+	if err := ctx.checkInputs(keys); err != nil {
+		return err
+	}
+	if err := ctx.useReads(keys); err != nil {
+		return err
+	}
 
-	result, err := ctx.ref.GetState(keys)
+	result, err := ctx.getState(keys)
 	if err != nil {
 		return GetState.TxErrore(err)
 	}
@@ -82,7 +183,11 @@ func (ctx *Context) GetAll(data map[string]proto.Message) *processor.InvalidTran
 		}
 		if v != nil {
 			// value in input data and umarshal result bytes from chain.
-			if err := proto.Unmarshal(b, v); err != nil {
+			data, txErr := ctx.decode(k, b, v)
+			if txErr != nil {
+				return txErr
+			}
+			if err := proto.Unmarshal(data, v); err != nil {
 				return Unmarshal.TxErrore(err)
 			}
 		}
@@ -107,24 +212,27 @@ func (ctx *Context) Get(address string, data proto.Message) (bool, *processor.In
 // ----------------------------------------------------------------------------
 
 // SetAll sets all data into chain.
+// Data are buffered until handler succeeds.
 func (ctx *Context) SetAll(data map[string]proto.Message) *processor.InvalidTransactionError {
 	tmp := make(map[string][]byte)
 
 	for k, v := range data {
-		dataBytes, err := proto.Marshal(v)
+		dataBytes, err := ctx.encode(v)
 		if err != nil {
 			return Marshal.TxErrore(err)
 		}
 		tmp[k] = dataBytes
 	}
 
-	resp, err := ctx.ref.SetState(tmp)
-	if err != nil {
-		return SetState.TxErrore(err)
+	if err := ctx.checkOutputs(mapKeys(data)); err != nil {
+		return err
+	}
+	if err := ctx.useWrites(tmp); err != nil {
+		return err
 	}
 
-	if len(resp) != len(data) {
-		return LenNotMatch.TxErrorf("length of responses (%d) are not same with input (%d)", len(resp), len(data))
+	for k, v := range tmp {
+		ctx.changes[k] = v
 	}
 
 	return nil
@@ -138,15 +246,96 @@ func (ctx *Context) Set(address string, data proto.Message) *processor.InvalidTr
 
 // ----------------------------------------------------------------------------
 
-// Del remove state from chain.
+// Del remove state from chain, and returns addresses existing in state.
+// Addresses not in state, including ones deleted before in the same transaction, are not returned.
+// Deletes are buffered until handler succeeds.
 func (ctx *Context) Del(addrs []string) ([]string, error) {
-	return ctx.ref.DeleteState(addrs)
+	tmp := make(map[string][]byte, len(addrs))
+	for _, x := range addrs {
+		tmp[x] = nil
+	}
+
+	if err := ctx.checkOutputs(addrs); err != nil {
+		return nil, err
+	}
+	if err := ctx.useWrites(tmp); err != nil {
+		return nil, err
+	}
+
+	found, err := ctx.getState(addrs)
+	if err != nil {
+		return nil, GetState.TxErrore(err)
+	}
+
+	var ret []string
+	for _, x := range addrs {
+		if _, ok := found[x]; ok {
+			ctx.changes[x] = nil
+			ret = append(ret, x)
+			delete(found, x)
+		}
+	}
+	return ret, nil
 }
 
 // ----------------------------------------------------------------------------
 
-// AddEvent adds event to chain.
-func (ctx *Context) AddEvent(typ string, data []byte, attributes ...processor.Attribute) *processor.InvalidTransactionError {
+// flush writes buffered changes into validator with one SetState and one DeleteState.
+func (ctx *Context) flush() *processor.InvalidTransactionError {
+	sets := make(map[string][]byte)
+	var dels []string
+
+	for k, v := range ctx.changes {
+		if v == nil {
+			dels = append(dels, k)
+		} else {
+			sets[k] = v
+		}
+	}
+
+	if len(sets) > 0 {
+		resp, err := ctx.ref.SetState(sets)
+		if err != nil {
+			return SetState.TxErrore(err)
+		}
+
+		if len(resp) != len(sets) {
+			return LenNotMatch.TxErrorf("length of responses (%d) are not same with input (%d)", len(resp), len(sets))
+		}
+	}
+
+	if len(dels) > 0 {
+		sort.Strings(dels)
+		if _, err := ctx.ref.DeleteState(dels); err != nil {
+			return SetState.TxErrore(err)
+		}
+	}
+
+	ctx.changes = make(map[string][]byte)
+	return nil
+}
+
+// discard drops buffered changes.
+func (ctx *Context) discard() {
+	ctx.changes = make(map[string][]byte)
+}
+
+// ----------------------------------------------------------------------------
+
+// attributeKeys returns keys of attributes.
+func attributeKeys(attributes []processor.Attribute) []string {
+	keys := make([]string, len(attributes))
+	for i, a := range attributes {
+		keys[i] = a.Key
+	}
+	return keys
+}
+
+// addEvent adds event to chain without checking.
+func (ctx *Context) addEvent(typ string, data []byte, attributes []processor.Attribute) *processor.InvalidTransactionError {
+	if err := ctx.useEvent(); err != nil {
+		return err
+	}
 
 	if err := ctx.ref.AddEvent(typ, attributes, data); err != nil {
 		return Events.TxErrore(err)
@@ -155,8 +344,32 @@ func (ctx *Context) AddEvent(typ string, data []byte, attributes ...processor.At
 	return nil
 }
 
+// AddEvent adds event to chain.
+// Data and attributes of registered event type are checked.
+func (ctx *Context) AddEvent(typ string, data []byte, attributes ...processor.Attribute) *processor.InvalidTransactionError {
+	if t, ok := event.Lookup(typ); ok {
+		msg, err := t.Decode(data)
+		if err == nil {
+			err = t.Check(msg, attributeKeys(attributes))
+		}
+
+		if err != nil {
+			return Events.TxErrore(err)
+		}
+	}
+
+	return ctx.addEvent(typ, data, attributes)
+}
+
 // AddEventMessage adds event to chain.
+// Data and attributes of registered event type are checked.
 func (ctx *Context) AddEventMessage(typ string, data proto.Message, attributes ...processor.Attribute) *processor.InvalidTransactionError {
+	if t, ok := event.Lookup(typ); ok {
+		if err := t.Check(data, attributeKeys(attributes)); err != nil {
+			return Events.TxErrore(err)
+		}
+	}
+
 	var dataBytes []byte
 	var err error
 
@@ -166,7 +379,7 @@ func (ctx *Context) AddEventMessage(typ string, data proto.Message, attributes .
 			return Marshal.TxErrore(err)
 		}
 	}
-	return ctx.AddEvent(typ, dataBytes, attributes...)
+	return ctx.addEvent(typ, dataBytes, attributes)
 }
 
 // ----------------------------------------------------------------------------
@@ -178,6 +391,9 @@ func (ctx *Context) AddReceiptData(data proto.Message) *processor.InvalidTransac
 	if err != nil {
 		return Marshal.TxErrore(err)
 	}
+	if err := ctx.useReceipt(len(databyes)); err != nil {
+		return err
+	}
 	if err := ctx.ref.AddReceiptData(databyes); err != nil {
 		return ReceiptData.TxErrore(err)
 	}
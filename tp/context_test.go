@@ -0,0 +1,132 @@
+package tp
+
+import (
+	"testing"
+
+	"github.com/dairaga/sawtk/event"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/setting_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+// countState is a fake state counting calls.
+type countState struct {
+	data map[string][]byte
+	gets int
+	sets int
+	dels int
+}
+
+func newCountState() *countState {
+	return &countState{data: make(map[string][]byte)}
+}
+
+func (s *countState) GetState(addrs []string) (map[string][]byte, error) {
+	s.gets++
+	ret := make(map[string][]byte)
+	for _, x := range addrs {
+		if b, ok := s.data[x]; ok {
+			ret[x] = b
+		}
+	}
+	return ret, nil
+}
+
+func (s *countState) SetState(pairs map[string][]byte) ([]string, error) {
+	s.sets++
+	var ret []string
+	for k, v := range pairs {
+		s.data[k] = v
+		ret = append(ret, k)
+	}
+	return ret, nil
+}
+
+func (s *countState) DeleteState(addrs []string) ([]string, error) {
+	s.dels++
+	for _, x := range addrs {
+		delete(s.data, x)
+	}
+	return addrs, nil
+}
+
+func (s *countState) AddReceiptData(data []byte) error { return nil }
+
+func (s *countState) AddEvent(typ string, attributes []processor.Attribute, data []byte) error {
+	return nil
+}
+
+func TestContextBuffer(t *testing.T) {
+	state := newCountState()
+	state.data["b"] = []byte{0x0a, 0x00}
+	ctx := newContext(state, &transaction_pb2.TransactionHeader{SignerPublicKey: "signer"}, 1)
+
+	entry := &setting_pb2.Setting_Entry{Key: "a", Value: "1"}
+	if err := ctx.Set("a", entry); err != nil {
+		t.Fatal(err)
+	}
+
+	got := new(setting_pb2.Setting_Entry)
+	if ok, err := ctx.Get("a", got); err != nil || !ok || got.Value != "1" {
+		t.Fatalf("read your writes: %v %v %v", ok, err, got)
+	}
+
+	if ok, _ := ctx.Get("b", nil); !ok {
+		t.Fatal("b must be found")
+	}
+
+	if deleted, err := ctx.Del([]string{"b", "missing"}); err != nil || len(deleted) != 1 || deleted[0] != "b" {
+		t.Fatalf("deleted want [b], but %v %v", deleted, err)
+	}
+
+	if ok, _ := ctx.Get("b", nil); ok {
+		t.Fatal("b must be deleted")
+	}
+
+	if deleted, err := ctx.Del([]string{"b"}); err != nil || len(deleted) != 0 {
+		t.Fatalf("deleted b can not be deleted again: %v %v", deleted, err)
+	}
+
+	if state.gets != 2 || state.sets != 0 || state.dels != 0 {
+		t.Fatalf("calls before flush: get %d, set %d, del %d", state.gets, state.sets, state.dels)
+	}
+
+	if err := ctx.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if state.sets != 1 || state.dels != 1 || len(state.data) != 1 {
+		t.Fatalf("calls after flush: set %d, del %d, data %d", state.sets, state.dels, len(state.data))
+	}
+}
+
+func TestContextEvent(t *testing.T) {
+	const entry = "tptest/entry"
+	// event registry panics on duplicates, so entry is registered once with -count.
+	if _, ok := event.Lookup(entry); !ok {
+		event.Register(entry, new(setting_pb2.Setting_Entry), "key")
+	}
+
+	ctx := newContext(newCountState(), &transaction_pb2.TransactionHeader{}, 1)
+	attr := processor.Attribute{Key: "key", Value: "a"}
+
+	if err := ctx.AddEventMessage(entry, &setting_pb2.Setting_Entry{Key: "a"}, attr); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ctx.AddEventMessage(entry, &setting_pb2.Setting{}, attr); err == nil || ToErrCode(err.ExtendedData) != Events {
+		t.Fatalf("other message want %v, but %v", Events, err)
+	}
+
+	if err := ctx.AddEvent(entry, []byte{0xff}, attr); err == nil || ToErrCode(err.ExtendedData) != Events {
+		t.Fatalf("bad data want %v, but %v", Events, err)
+	}
+
+	if err := ctx.AddEventMessage(entry, &setting_pb2.Setting_Entry{Key: "a"}); err == nil || ToErrCode(err.ExtendedData) != Events {
+		t.Fatalf("missing attribute want %v, but %v", Events, err)
+	}
+
+	if err := ctx.AddEvent("tptest/unregistered", []byte{0xff}); err != nil {
+		t.Fatal(err)
+	}
+}
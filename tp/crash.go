@@ -0,0 +1,154 @@
+package tp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/dairaga/log"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/processor_pb2"
+)
+
+// max frames in stack of error returned for panics.
+const maxStackFrames = 16
+
+// CrashReport is a panic in handler with the request causing it.
+type CrashReport struct {
+	Time    time.Time `json:"time"`
+	Family  string    `json:"family"`
+	Version string    `json:"version"`
+	Signer  string    `json:"signer"`
+	Panic   string    `json:"panic"`
+	Stack   string    `json:"stack"`
+	Request []byte    `json:"request"` // TpProcessRequest in protobuf.
+}
+
+func (r *CrashReport) String() string {
+	return fmt.Sprintf(`{"time": "%s", "family": "%s", "version": "%s", "signer": "%s", "panic": %q}`,
+		r.Time.Format(time.RFC3339), r.Family, r.Version, r.Signer, r.Panic)
+}
+
+// ReadCrashReport returns crash report in file.
+func ReadCrashReport(file string) (*CrashReport, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(CrashReport)
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Replay handles request in report again with handler and state.
+func (r *CrashReport) Replay(h *Handler, state State) error {
+	req := new(processor_pb2.TpProcessRequest)
+	if err := proto.Unmarshal(r.Request, req); err != nil {
+		return err
+	}
+	return h.Handle(req, state)
+}
+
+// ----------------------------------------------------------------------------
+
+// Debug sets debug mode, and overrides environment variable TP_DEBUG ("true" means debug mode).
+// Panics in debug mode are not recovered.
+func (h *Handler) Debug(flag bool) {
+	h.debug = flag
+}
+
+// CrashDir sets directory of crash reports written when handler panics.
+// Empty means no crash reports.
+func (h *Handler) CrashDir(dir string) {
+	h.crashDir = dir
+}
+
+// redactedStack returns functions and lines of callers without paths and arguments,
+// ex: tp.(*Handler).Handle (handler.go:151).
+func redactedStack(skip int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var lines []string
+	for len(lines) < maxStackFrames {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			fn := f.Function[strings.LastIndex(f.Function, "/")+1:]
+			lines = append(lines, fmt.Sprintf("%s (%s:%d)", fn, filepath.Base(f.File), f.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// crashID returns id of crash report of req, which is the prefix of transaction signature.
+func crashID(req *processor_pb2.TpProcessRequest, t time.Time) string {
+	id := req.Signature
+	if len(id) > 16 {
+		id = id[:16]
+	}
+	if id == "" {
+		id = fmt.Sprint(t.UnixNano())
+	}
+	return id
+}
+
+// crashed writes crash report of panic r in handling req, and returns an Internal error with report id and redacted stack.
+// Panic value and report file are only in logs, not in the error sent to validator.
+func (h *Handler) crashed(req *processor_pb2.TpProcessRequest, r interface{}) *processor.InvalidTransactionError {
+	report := &CrashReport{
+		Time:    time.Now().UTC(),
+		Family:  req.GetHeader().GetFamilyName(),
+		Version: req.GetHeader().GetFamilyVersion(),
+		Signer:  req.GetHeader().GetSignerPublicKey(),
+		Panic:   fmt.Sprint(r),
+		Stack:   string(debug.Stack()),
+	}
+	id := crashID(req, report.Time)
+	log.Errorf("handler panic (%s): %v\n%s", id, r, report.Stack)
+
+	if h.crashDir != "" {
+		file, err := h.writeCrashReport(id, req, report)
+		if err != nil {
+			log.Errorf("write crash report: %v", err)
+		} else {
+			log.Errorf("crash report: %s", file)
+		}
+	}
+
+	return Internal.TxErrorf("panic, crash %s\n%s", id, redactedStack(3))
+}
+
+// writeCrashReport writes report with req into crash directory, and returns file name.
+// Reports of the same transaction are written into the same file.
+func (h *Handler) writeCrashReport(id string, req *processor_pb2.TpProcessRequest, report *CrashReport) (string, error) {
+	var err error
+	if report.Request, err = proto.Marshal(req); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(h.crashDir, 0755); err != nil {
+		return "", err
+	}
+
+	file := filepath.Join(h.crashDir, fmt.Sprintf("crash-%s.json", id))
+	return file, ioutil.WriteFile(file, data, 0644)
+}
@@ -0,0 +1,68 @@
+package tp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/processor_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+func TestCrash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	h := NewHandler(NewFamily("crashtest", []string{"1.0"}, []string{"000000"}))
+	h.CrashDir(dir)
+	h.Add(1, func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+		var m map[string]int
+		m["boom"] = 1
+		return nil
+	})
+
+	payload, _ := NewTPRequestBytes(1, nil)
+	req := &processor_pb2.TpProcessRequest{
+		Header:    &transaction_pb2.TransactionHeader{FamilyName: "crashtest", FamilyVersion: "1.0", SignerPublicKey: "alice"},
+		Payload:   payload,
+		Signature: "0123456789abcdef0123",
+	}
+
+	err = h.Handle(req, newCountState())
+	txErr, ok := err.(*processor.InvalidTransactionError)
+	if !ok || ToErrCode(txErr.ExtendedData) != Internal {
+		t.Fatalf("panic want %v, but %v", Internal, err)
+	}
+	if !strings.Contains(txErr.Msg, "crash 0123456789abcdef\n") || !strings.Contains(txErr.Msg, "TestCrash") {
+		t.Errorf("error must have crash id and stack: %s", txErr.Msg)
+	}
+	if strings.Contains(txErr.Msg, "/") || strings.Contains(txErr.Msg, "nil map") || strings.Contains(txErr.Msg, ".json") {
+		t.Errorf("error must only have crash id and redacted stack: %s", txErr.Msg)
+	}
+
+	report, err := ReadCrashReport(filepath.Join(dir, "crash-0123456789abcdef.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Family != "crashtest" || report.Signer != "alice" || !strings.Contains(report.Panic, "nil map") {
+		t.Errorf("report: %v", report)
+	}
+
+	if err := report.Replay(h, newCountState()); err == nil {
+		t.Error("replay must fail again")
+	}
+
+	h.Debug(true)
+	defer func() {
+		if recover() == nil {
+			t.Error("debug mode must not recover")
+		}
+	}()
+	report.Replay(h, newCountState())
+}
@@ -0,0 +1,68 @@
+package tp
+
+import (
+	"strings"
+
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+)
+
+// declared returns address is covered by addresses or prefixes in list.
+func declared(list []string, address string) bool {
+	for _, x := range list {
+		if strings.HasPrefix(address, x) {
+			return true
+		}
+	}
+	return false
+}
+
+// checksDeclared returns context checks addresses against transaction header or not.
+// Headers without inputs and outputs are not checked, ex: contexts in tests.
+func (ctx *Context) checksDeclared() bool {
+	return ctx.header != nil && (len(ctx.header.Inputs) > 0 || len(ctx.header.Outputs) > 0)
+}
+
+// namespaceOf returns name of namespace address belongs to, or its prefix if unknown.
+func (ctx *Context) namespaceOf(address string) string {
+	if len(address) < 6 {
+		return address
+	}
+
+	prefix := address[:6]
+	if prefix == "000000" {
+		return "settings (000000)"
+	}
+
+	if name, ok := ctx.namespaces[prefix]; ok {
+		return name + " (" + prefix + ")"
+	}
+	return prefix
+}
+
+// checkInputs returns error if any address is not in inputs of transaction header.
+func (ctx *Context) checkInputs(addresses []string) *processor.InvalidTransactionError {
+	if !ctx.checksDeclared() {
+		return nil
+	}
+
+	for _, x := range addresses {
+		if !declared(ctx.header.Inputs, x) {
+			return Undeclared.TxErrorf("address %s in namespace %s is not in inputs", x, ctx.namespaceOf(x))
+		}
+	}
+	return nil
+}
+
+// checkOutputs returns error if any address is not in outputs of transaction header.
+func (ctx *Context) checkOutputs(addresses []string) *processor.InvalidTransactionError {
+	if !ctx.checksDeclared() {
+		return nil
+	}
+
+	for _, x := range addresses {
+		if !declared(ctx.header.Outputs, x) {
+			return Undeclared.TxErrorf("address %s in namespace %s is not in outputs", x, ctx.namespaceOf(x))
+		}
+	}
+	return nil
+}
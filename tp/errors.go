@@ -75,6 +75,10 @@ func (c ErrCode) String() string {
 
 // Errors of tp.
 const (
+	Undeclared    ErrCode = 999983 // address not in inputs or outputs.
+	OverBudget    ErrCode = 999984 // resources of transaction over budget.
+	Migrate       ErrCode = 999985 // state migration failure.
+	Forbidden     ErrCode = 999986 // signer is not allowed.
 	Internal      ErrCode = 999987 // internal error.
 	Wallet        ErrCode = 999988 // generating wallet failure.
 	BadParameters ErrCode = 999989 // any fields in request is invalid.
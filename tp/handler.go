@@ -14,9 +14,9 @@ import (
 
 // -----------------------------------------------------------------------------------
 
-// unmarshal bytes to protobuf message.
-func unmarshal(buf []byte, pb proto.Message) *processor.InvalidTransactionError {
-	if err := proto.Unmarshal(buf, pb); err != nil {
+// unmarshal payload of request to protobuf message.
+func unmarshal(req *TPRequest, pb proto.Message) *processor.InvalidTransactionError {
+	if err := req.Unmarshal(pb); err != nil {
 		return Unmarshal.TxErrore(err)
 	}
 
@@ -80,7 +80,7 @@ func MakeHandlerFunc(f interface{}) HandlerFunc {
 
 			newReq := reflect.New(ftype.In(1).Elem())
 
-			if err := unmarshal(req.Payload, newReq.Interface().(proto.Message)); err != nil {
+			if err := unmarshal(req, newReq.Interface().(proto.Message)); err != nil {
 				return []reflect.Value{reflect.ValueOf(err)}
 			}
 
@@ -102,63 +102,124 @@ func MakeHandlerFunc(f interface{}) HandlerFunc {
 // Handler is a handler for Sawtooth Transaction Processor.
 type Handler struct {
 	*Family
-	router map[int32]HandlerFunc
-	debug  bool
+	router         map[int32]HandlerFunc
+	versionRouter  map[string]map[int32]HandlerFunc
+	middlewares    []Middleware
+	cmdMiddlewares map[int32][]Middleware
+	schemas        map[string]*Schema
+	encodings      map[string]string // encodings of family versions.
+	budget         Budget
+	debug          bool   // panics are not recovered in debug mode.
+	crashDir       string // directory of crash reports.
 }
 
 // Apply implements Apply function of processor.TransactionHandler.
 // Decode payload in req (*processor_pb2.TpProcessRequest), and change to user's payload.
 func (h *Handler) Apply(req *processor_pb2.TpProcessRequest, ctx *processor.Context) error {
-	r := new(TPRequest)
+	return h.Handle(req, ctx)
+}
+
+// Handle handles req with state.
+// It is the same as Apply, but state can be any implementation, ex: a fake state in tests.
+func (h *Handler) Handle(req *processor_pb2.TpProcessRequest, ctx State) error {
+	_, err := h.handle(req, ctx)
+	return err
+}
 
-	// 加一個環境變數，來設定是否要進入 debug mode,
-	// 如果是 debug mode 則取消 recover.
+// handle handles req with state, and returns command and final result of req,
+// including decoding, routing, flushing and panic errors.
+func (h *Handler) handle(req *processor_pb2.TpProcessRequest, ctx State) (cmd int32, err error) {
+	// debug mode 不 recover, 方便除錯.
 	if !h.debug {
-		defer func(r *TPRequest) {
+		defer func() {
 			if r := recover(); r != nil {
-				log.Fatal(r)
-				fmt.Println(r)
+				err = h.crashed(req, r)
 			}
-		}(r)
+		}()
 	}
 
-	if err := proto.Unmarshal(req.Payload, r); err != nil {
-		return Unmarshal.TxErrore(err)
+	r, err := h.decodeRequest(req.Header.FamilyVersion, req.Payload)
+	if err != nil {
+		return 0, Unmarshal.TxErrore(err)
 	}
+	cmd = r.Cmd
 
 	log.Debugf("got CMD (%d)", r.Cmd)
-	hfunc, ok := h.router[r.Cmd]
-	if !ok || hfunc == nil {
-		return &processor.InvalidTransactionError{
-			Msg: fmt.Sprintf("unknow cmd: %d", r.Cmd),
+
+	var txErr *processor.InvalidTransactionError
+	ctxw := h.newContext(ctx, req, r.Cmd)
+
+	if len(r.Requests) > 0 {
+		txErr = h.handleMulti(ctxw, req.Header.FamilyVersion, r.Requests)
+	} else {
+		hfunc, ok := h.route(req.Header.FamilyVersion, r.Cmd)
+		if !ok {
+			return cmd, UnknownCmd.TxErrorf("unknow cmd: %d", r.Cmd)
 		}
+		txErr = hfunc(ctxw, r)
 	}
+	log.Debugf("cmd %d usage: %v, budget: %v", r.Cmd, ctxw.Usage(), h.budget)
 
-	ctxw := &Context{
-		ref:    ctx,
-		signer: req.Header.SignerPublicKey,
-		cmd:    r.Cmd,
+	if txErr != nil {
+		ctxw.discard()
+		log.Debugf("cmd %d handler: %v", r.Cmd, txErr)
+		fmt.Println("handle", r.Cmd, txErr)
+		return cmd, txErr
 	}
 
-	if err := hfunc(ctxw, r); err != nil {
-		log.Debugf("cmd %d handler: %v", r.Cmd, err)
-		fmt.Println("handle", r.Cmd, err)
-		return err
+	if err := ctxw.flush(); err != nil {
+		return cmd, err
 	}
 	log.Debugf("CMD (%d) end", r.Cmd)
-	return nil
+	return cmd, nil
+}
+
+// newContext returns context of request with settings of handler.
+func (h *Handler) newContext(ctx State, req *processor_pb2.TpProcessRequest, cmd int32) *Context {
+	ctxw := newContext(ctx, req.Header, cmd)
+	ctxw.schemas = h.schemas
+	ctxw.budget = h.budget
+	ctxw.namespaces = make(map[string]string, len(h.Namespaces()))
+	for _, x := range h.Namespaces() {
+		ctxw.namespaces[x] = h.FamilyName()
+	}
+	return ctxw
 }
 
 // Add an handler function for some command.
-func (h *Handler) Add(cmd int32, hf HandlerFunc) {
+// Middlewares are only applied to the command.
+func (h *Handler) Add(cmd int32, hf HandlerFunc, mws ...Middleware) {
 	h.router[cmd] = hf
+	h.UseFor(cmd, mws...)
+}
+
+// AddVersion adds an handler function for some command in a family version.
+// Commands without handler of the version fall back to handler added by Add.
+func (h *Handler) AddVersion(version string, cmd int32, hf HandlerFunc) {
+	supported := false
+	for _, x := range h.versions {
+		supported = supported || x == version
+	}
+
+	if !supported {
+		panic(fmt.Sprintf("family %s does not support version %s", h.name, version))
+	}
+
+	if h.versionRouter[version] == nil {
+		h.versionRouter[version] = make(map[int32]HandlerFunc)
+	}
+	h.versionRouter[version][cmd] = hf
 }
 
 // NewHandler returns a SawTK handler.
 func NewHandler(family *Family) *Handler {
 	return &Handler{
-		family,
-		make(map[int32]HandlerFunc),
-		os.Getenv("TP_DEBUG") == "true",
+		Family:         family,
+		router:         make(map[int32]HandlerFunc),
+		versionRouter:  make(map[string]map[int32]HandlerFunc),
+		cmdMiddlewares: make(map[int32][]Middleware),
+		schemas:        make(map[string]*Schema),
+		encodings:      make(map[string]string),
+		debug:          os.Getenv("TP_DEBUG") == "true",
 	}
 }
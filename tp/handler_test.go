@@ -0,0 +1,46 @@
+package tp
+
+import (
+	"testing"
+
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/processor_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+func TestVersionRoute(t *testing.T) {
+	h := NewHandler(NewFamily("vtest", []string{"1.0", "1.1"}, []string{"000000"}))
+
+	var got string
+	h.Add(1, func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+		got = "default " + ctx.Version()
+		return nil
+	})
+	h.AddVersion("1.1", 1, func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+		got = "v1.1 " + ctx.Version()
+		return nil
+	})
+
+	payload, _ := NewTPRequestBytes(1, nil)
+	for version, want := range map[string]string{"1.0": "default 1.0", "1.1": "v1.1 1.1"} {
+		req := &processor_pb2.TpProcessRequest{
+			Header:  &transaction_pb2.TransactionHeader{FamilyVersion: version},
+			Payload: payload,
+		}
+
+		if err := h.Handle(req, newCountState()); err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Errorf("version %s want %q, but %q", version, want, got)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("unsupported version must panic")
+		}
+	}()
+	h.AddVersion("2.0", 1, nil)
+}
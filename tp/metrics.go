@@ -0,0 +1,229 @@
+package tp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+)
+
+// number of latest latencies of a command kept for percentiles.
+const metricsWindow = 1024
+
+var quantiles = []float64{0.5, 0.9, 0.99}
+
+type metricsKey struct {
+	family  string
+	version string
+	cmd     int32
+}
+
+func (k metricsKey) labels() string {
+	return fmt.Sprintf(`family="%s",version="%s",cmd="%d"`, k.family, k.version, k.cmd)
+}
+
+type cmdMetrics struct {
+	count     uint64
+	sum       float64
+	errors    map[ErrCode]uint64
+	latencies []float64 // ring buffer of seconds.
+	next      int
+}
+
+func (c *cmdMetrics) observe(seconds float64, code ErrCode, failed bool) {
+	c.count++
+	c.sum += seconds
+	if failed {
+		c.errors[code]++
+	}
+
+	if len(c.latencies) < metricsWindow {
+		c.latencies = append(c.latencies, seconds)
+		return
+	}
+	c.latencies[c.next] = seconds
+	c.next = (c.next + 1) % metricsWindow
+}
+
+// quantile returns q quantile of sorted values.
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) <= 0 {
+		return 0
+	}
+	return sorted[int(q*float64(len(sorted)-1)+0.5)]
+}
+
+// ----------------------------------------------------------------------------
+
+// Metrics counts requests, errors and latencies of commands.
+type Metrics struct {
+	mux  sync.Mutex
+	cmds map[metricsKey]*cmdMetrics
+}
+
+// NewMetrics returns an empty metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		cmds: make(map[metricsKey]*cmdMetrics),
+	}
+}
+
+// Observe records elapsed time and result of a request.
+func (m *Metrics) Observe(ctx *Context, elapsed time.Duration, err *processor.InvalidTransactionError) {
+	var e error
+	if err != nil {
+		e = err
+	}
+	m.Record(ctx.Header().GetFamilyName(), ctx.Version(), ctx.Cmd(), elapsed, e)
+}
+
+// Record records elapsed time and result of a command.
+// Errors without error code are counted with code 0.
+func (m *Metrics) Record(family, version string, cmd int32, elapsed time.Duration, err error) {
+	key := metricsKey{family, version, cmd}
+
+	code := ErrCode(0)
+	if x, ok := err.(*processor.InvalidTransactionError); ok && x != nil && len(x.ExtendedData) == 4 {
+		code = ToErrCode(x.ExtendedData)
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	c, ok := m.cmds[key]
+	if !ok {
+		c = &cmdMetrics{errors: make(map[ErrCode]uint64)}
+		m.cmds[key] = c
+	}
+	c.observe(elapsed.Seconds(), code, err != nil)
+}
+
+// Middleware returns a Timing middleware recording into metrics.
+// It only sees requests routed to handler functions; Runner records all requests of its handlers.
+func (m *Metrics) Middleware() Middleware {
+	return Timing(m.Observe)
+}
+
+// WriteTo writes metrics in Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	keys := make([]metricsKey, 0, len(m.cmds))
+	for k := range m.cmds {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].family != keys[j].family {
+			return keys[i].family < keys[j].family
+		}
+		if keys[i].version != keys[j].version {
+			return keys[i].version < keys[j].version
+		}
+		return keys[i].cmd < keys[j].cmd
+	})
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+
+	fmt.Fprintln(cw, "# HELP sawtk_tp_requests_total Number of requests handled by command.")
+	fmt.Fprintln(cw, "# TYPE sawtk_tp_requests_total counter")
+	for _, k := range keys {
+		fmt.Fprintf(cw, "sawtk_tp_requests_total{%s} %d\n", k.labels(), m.cmds[k].count)
+	}
+
+	fmt.Fprintln(cw, "# HELP sawtk_tp_errors_total Number of failed requests by command and error code.")
+	fmt.Fprintln(cw, "# TYPE sawtk_tp_errors_total counter")
+	for _, k := range keys {
+		c := m.cmds[k]
+		codes := make([]ErrCode, 0, len(c.errors))
+		for code := range c.errors {
+			codes = append(codes, code)
+		}
+		sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+
+		for _, code := range codes {
+			fmt.Fprintf(cw, "sawtk_tp_errors_total{%s,code=\"%d\"} %d\n", k.labels(), code, c.errors[code])
+		}
+	}
+
+	fmt.Fprintln(cw, "# HELP sawtk_tp_request_seconds Latency of requests by command.")
+	fmt.Fprintln(cw, "# TYPE sawtk_tp_request_seconds summary")
+	for _, k := range keys {
+		c := m.cmds[k]
+		sorted := append([]float64(nil), c.latencies...)
+		sort.Float64s(sorted)
+
+		for _, q := range quantiles {
+			fmt.Fprintf(cw, "sawtk_tp_request_seconds{%s,quantile=\"%g\"} %g\n", k.labels(), q, quantile(sorted, q))
+		}
+		fmt.Fprintf(cw, "sawtk_tp_request_seconds_sum{%s} %g\n", k.labels(), c.sum)
+		fmt.Fprintf(cw, "sawtk_tp_request_seconds_count{%s} %d\n", k.labels(), c.count)
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// countWriter counts written bytes and keeps the first error.
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+// ----------------------------------------------------------------------------
+
+// ServeHTTP serves liveness at /healthz, readiness at /readyz and metrics at /metrics.
+// Runner is ready after all handlers are registered with validator.
+func (r *Runner) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	status := r.Status()
+
+	switch req.URL.Path {
+	case "/healthz":
+		if status.State == Stopped {
+			http.Error(w, status.String(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, status)
+	case "/readyz":
+		if status.State != Registered {
+			http.Error(w, status.String(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, status)
+	case "/metrics":
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		registered := 0
+		if status.State == Registered {
+			registered = 1
+		}
+		fmt.Fprintln(w, "# HELP sawtk_tp_registered Whether handlers are registered with validator.")
+		fmt.Fprintln(w, "# TYPE sawtk_tp_registered gauge")
+		fmt.Fprintf(w, "sawtk_tp_registered %d\n", registered)
+		fmt.Fprintln(w, "# HELP sawtk_tp_restart_attempts Failed attempts since last registration.")
+		fmt.Fprintln(w, "# TYPE sawtk_tp_restart_attempts gauge")
+		fmt.Fprintf(w, "sawtk_tp_restart_attempts %d\n", status.Attempts)
+
+		r.metrics.WriteTo(w)
+	default:
+		http.NotFound(w, req)
+	}
+}
@@ -0,0 +1,104 @@
+package tp
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/processor_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics()
+	header := &transaction_pb2.TransactionHeader{FamilyName: "mtest", FamilyVersion: "1.0"}
+	ctx := newContext(newCountState(), header, 1)
+
+	m.Observe(ctx, 10*time.Millisecond, nil)
+	m.Observe(ctx, 20*time.Millisecond, NotFound.TxErrorf("not found"))
+	m.Observe(ctx, 30*time.Millisecond, NotFound.TxErrorf("not found"))
+
+	buf := new(bytes.Buffer)
+	if _, err := m.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+
+	wants := []string{
+		`sawtk_tp_requests_total{family="mtest",version="1.0",cmd="1"} 3`,
+		`sawtk_tp_errors_total{family="mtest",version="1.0",cmd="1",code="999992"} 2`,
+		`sawtk_tp_request_seconds{family="mtest",version="1.0",cmd="1",quantile="0.5"} 0.02`,
+		`sawtk_tp_request_seconds_count{family="mtest",version="1.0",cmd="1"} 3`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics want %s, but\n%s", want, text)
+		}
+	}
+}
+
+func TestRunnerHTTP(t *testing.T) {
+	r := NewRunner("invalid")
+
+	for path, code := range map[string]int{
+		"/healthz": http.StatusServiceUnavailable,
+		"/readyz":  http.StatusServiceUnavailable,
+		"/metrics": http.StatusOK,
+		"/other":   http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != code {
+			t.Errorf("%s want %d, but %d", path, code, w.Code)
+		}
+	}
+
+	r.setState(Registered, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("/readyz want %d, but %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestRunnerMetrics(t *testing.T) {
+	h := NewHandler(NewFamily("rmtest", []string{"1.0"}, []string{"abcdef"}))
+	h.Add(1, func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+		panic("boom")
+	})
+
+	r := NewRunner("invalid").Add(h).Add(h)
+	if len(h.middlewares) != 0 {
+		t.Fatal("runner must not change handler")
+	}
+
+	o := &observed{Handler: h, metrics: r.Metrics()}
+	header := &transaction_pb2.TransactionHeader{FamilyName: "rmtest", FamilyVersion: "1.0"}
+	unknown, _ := NewTPRequestBytes(2, nil)
+	panics, _ := NewTPRequestBytes(1, nil)
+
+	for _, payload := range [][]byte{[]byte("bad payload"), unknown, panics} {
+		if err := o.Handle(&processor_pb2.TpProcessRequest{Header: header, Payload: payload}, newCountState()); err == nil {
+			t.Fatal("request must fail")
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	r.Metrics().WriteTo(buf)
+	text := buf.String()
+
+	for _, want := range []string{
+		fmt.Sprintf(`sawtk_tp_errors_total{family="rmtest",version="1.0",cmd="0",code="%d"} 1`, Unmarshal),
+		fmt.Sprintf(`sawtk_tp_errors_total{family="rmtest",version="1.0",cmd="2",code="%d"} 1`, UnknownCmd),
+		fmt.Sprintf(`sawtk_tp_errors_total{family="rmtest",version="1.0",cmd="1",code="%d"} 1`, Internal),
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics want %s, but\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package tp
+
+import (
+	"time"
+
+	"github.com/dairaga/log"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+)
+
+// Middleware wraps a HandlerFunc and returns a new one.
+type Middleware func(HandlerFunc) HandlerFunc
+
+// Chain returns a HandlerFunc wrapped by middlewares.
+// The first middleware is the outermost one.
+func Chain(hf HandlerFunc, mws ...Middleware) HandlerFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		hf = mws[i](hf)
+	}
+	return hf
+}
+
+// ----------------------------------------------------------------------------
+
+// Logging logs command, signer, elapsed time and result of each request.
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+			start := time.Now()
+			err := next(ctx, req)
+			elapsed := time.Since(start)
+
+			if err != nil {
+				log.Errorf(`{"cmd": %d, "signer": "%s", "elapsed": "%v", "error": %q}`, ctx.Cmd(), ctx.SignerPublicKey(), elapsed, err.Msg)
+			} else {
+				log.Infof(`{"cmd": %d, "signer": "%s", "elapsed": "%v"}`, ctx.Cmd(), ctx.SignerPublicKey(), elapsed)
+			}
+			return err
+		}
+	}
+}
+
+// Timing calls observe with elapsed time and result of each request.
+func Timing(observe func(ctx *Context, elapsed time.Duration, err *processor.InvalidTransactionError)) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+			start := time.Now()
+			err := next(ctx, req)
+			observe(ctx, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+// Recover converts panics in handler to Internal errors with redacted stack. Panic value is only in logs.
+// Handler already recovers panics and writes crash reports, so Recover must not wrap handlers run by
+// Handler.Handle if crash reports are wanted. It is for handler functions called without Handler.
+func Recover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context, req *TPRequest) (err *processor.InvalidTransactionError) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Errorf("cmd %d panic: %v", ctx.Cmd(), r)
+					err = Internal.TxErrorf("cmd %d panic\n%s", ctx.Cmd(), redactedStack(3))
+				}
+			}()
+
+			return next(ctx, req)
+		}
+	}
+}
+
+// AllowSigners rejects requests not signed by one of public keys.
+func AllowSigners(pubkeys ...string) Middleware {
+	allowed := make(map[string]bool, len(pubkeys))
+	for _, x := range pubkeys {
+		allowed[x] = true
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+			if !allowed[ctx.SignerPublicKey()] {
+				return Forbidden.TxErrorf("signer %s is not allowed for cmd %d", ctx.SignerPublicKey(), ctx.Cmd())
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+// Use appends middlewares for all commands.
+// Middlewares of handler wrap middlewares of command, and are applied in order of appending.
+func (h *Handler) Use(mws ...Middleware) {
+	h.middlewares = append(h.middlewares, mws...)
+}
+
+// UseFor appends middlewares for a command.
+func (h *Handler) UseFor(cmd int32, mws ...Middleware) {
+	h.cmdMiddlewares[cmd] = append(h.cmdMiddlewares[cmd], mws...)
+}
+
+// route returns handler function of command in version wrapped by middlewares.
+func (h *Handler) route(version string, cmd int32) (HandlerFunc, bool) {
+	hf, ok := h.versionRouter[version][cmd]
+	if !ok || hf == nil {
+		hf, ok = h.router[cmd]
+	}
+
+	if !ok || hf == nil {
+		return nil, false
+	}
+
+	mws := make([]Middleware, 0, len(h.middlewares)+len(h.cmdMiddlewares[cmd]))
+	mws = append(mws, h.middlewares...)
+	mws = append(mws, h.cmdMiddlewares[cmd]...)
+
+	return Chain(hf, mws...), true
+}
@@ -0,0 +1,70 @@
+package tp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+				order = append(order, name)
+				return next(ctx, req)
+			}
+		}
+	}
+
+	h := NewHandler(NewFamily("mwtest", []string{"1.0"}, []string{"000000"}))
+	h.Use(mark("a"), mark("b"))
+	h.Add(1, func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+		order = append(order, "handler")
+		return nil
+	}, mark("c"))
+
+	hf, ok := h.route("1.0", 1)
+	if !ok {
+		t.Fatal("cmd 1 not found")
+	}
+
+	if err := hf(&Context{cmd: 1}, &TPRequest{Cmd: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a", "b", "c", "handler"}
+	for i := range want {
+		if i >= len(order) || order[i] != want[i] {
+			t.Fatalf("order want %v, but %v", want, order)
+		}
+	}
+}
+
+func TestBuiltinMiddlewares(t *testing.T) {
+	boom := func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+		panic("boom")
+	}
+
+	err := Chain(boom, Recover())(&Context{cmd: 1}, &TPRequest{Cmd: 1})
+	if err == nil || ToErrCode(err.ExtendedData) != Internal {
+		t.Fatalf("panic must be converted to %v, but %v", Internal, err)
+	}
+	if strings.Contains(err.Msg, "boom") || !strings.Contains(err.Msg, "TestBuiltinMiddlewares") {
+		t.Errorf("error must only have redacted stack: %s", err.Msg)
+	}
+
+	ok := func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+		return nil
+	}
+
+	hf := Chain(ok, AllowSigners("alice"))
+	if err := hf(&Context{signer: "bob"}, &TPRequest{}); err == nil || ToErrCode(err.ExtendedData) != Forbidden {
+		t.Errorf("bob must be forbidden, but %v", err)
+	}
+
+	if err := hf(&Context{signer: "alice"}, &TPRequest{}); err != nil {
+		t.Errorf("alice must be allowed, but %v", err)
+	}
+}
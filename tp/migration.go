@@ -0,0 +1,172 @@
+package tp
+
+//go:generate protoc -I . --go_out=plugins=grpc:../../../../ record.proto
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+)
+
+// Upgrader upgrades data of a schema version to the next version.
+type Upgrader func(data []byte) ([]byte, error)
+
+// Schema describes versions of a protobuf message stored in state.
+// Stored data are wrapped in Record with type and version.
+// Data stored without Record are version 0.
+type Schema struct {
+	name      string
+	version   uint32
+	upgraders map[uint32]Upgrader
+	writeBack bool
+}
+
+// NewSchema returns a schema of pb in current version.
+// version must be greater than 0.
+func NewSchema(pb proto.Message, version uint32) *Schema {
+	return NewSchemaName(proto.MessageName(pb), version)
+}
+
+// NewSchemaName returns a schema of protobuf message name in current version.
+func NewSchemaName(name string, version uint32) *Schema {
+	if name == "" || version <= 0 {
+		panic("a schema must have a name and version greater than 0")
+	}
+
+	return &Schema{
+		name:      name,
+		version:   version,
+		upgraders: make(map[uint32]Upgrader),
+	}
+}
+
+func (s *Schema) String() string {
+	return fmt.Sprintf(`{"type": "%s", "version": %d, "write_back": %t}`, s.name, s.version, s.writeBack)
+}
+
+// Name returns protobuf message name of schema.
+func (s *Schema) Name() string {
+	return s.name
+}
+
+// Version returns current version of schema.
+func (s *Schema) Version() uint32 {
+	return s.version
+}
+
+// Upgrade registers an upgrader from version to version+1.
+func (s *Schema) Upgrade(from uint32, f Upgrader) *Schema {
+	if from >= s.version {
+		panic(fmt.Sprintf("schema %s: can not upgrade from version %d to %d", s.name, from, from+1))
+	}
+
+	s.upgraders[from] = f
+	return s
+}
+
+// WriteBack sets upgraded data are written back into state when reading.
+func (s *Schema) WriteBack(flag bool) *Schema {
+	s.writeBack = flag
+	return s
+}
+
+// Unwrap returns data and version of stored raw data.
+func (s *Schema) Unwrap(raw []byte) ([]byte, uint32) {
+	r := new(Record)
+	if err := proto.Unmarshal(raw, r); err != nil || r.Type != s.name || r.Version <= 0 {
+		return raw, 0
+	}
+
+	return r.Data, r.Version
+}
+
+// Encode wraps data of current version in Record.
+func (s *Schema) Encode(data []byte) ([]byte, error) {
+	return proto.Marshal(&Record{
+		Type:    s.name,
+		Version: s.version,
+		Data:    data,
+	})
+}
+
+// Decode returns data of current version from stored raw data.
+// upgraded is true if raw data are in older version.
+func (s *Schema) Decode(raw []byte) (data []byte, upgraded bool, err error) {
+	data, v := s.Unwrap(raw)
+	if v > s.version {
+		return nil, false, fmt.Errorf("schema %s: version %d is newer than %d", s.name, v, s.version)
+	}
+
+	for ; v < s.version; v++ {
+		f, ok := s.upgraders[v]
+		if !ok {
+			return nil, false, fmt.Errorf("schema %s: no upgrader from version %d", s.name, v)
+		}
+
+		data, err = f(data)
+		if err != nil {
+			return nil, false, fmt.Errorf("schema %s: upgrade from version %d: %v", s.name, v, err)
+		}
+		upgraded = true
+	}
+
+	return data, upgraded, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// Schema registers schemas of stored messages.
+// Context reads and writes registered messages through their schemas.
+func (h *Handler) Schema(ss ...*Schema) {
+	for _, s := range ss {
+		h.schemas[s.name] = s
+	}
+}
+
+// SchemaOf returns registered schema of pb.
+func (h *Handler) SchemaOf(pb proto.Message) (*Schema, bool) {
+	s, ok := h.schemas[proto.MessageName(pb)]
+	return s, ok
+}
+
+// ----------------------------------------------------------------------------
+
+// decode returns protobuf bytes of message from stored data at address.
+func (ctx *Context) decode(address string, raw []byte, pb proto.Message) ([]byte, *processor.InvalidTransactionError) {
+	s, ok := ctx.schemas[proto.MessageName(pb)]
+	if !ok {
+		return raw, nil
+	}
+
+	data, upgraded, err := s.Decode(raw)
+	if err != nil {
+		return nil, Migrate.TxErrore(err)
+	}
+
+	writable := !ctx.checksDeclared() || declared(ctx.header.Outputs, address)
+	if upgraded && s.writeBack && writable {
+		tmp, err := s.Encode(data)
+		if err != nil {
+			return nil, Marshal.TxErrore(err)
+		}
+		ctx.changes[address] = tmp
+	}
+
+	return data, nil
+}
+
+// encode returns stored data of message.
+func (ctx *Context) encode(pb proto.Message) ([]byte, error) {
+	data, err := proto.Marshal(pb)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := ctx.schemas[proto.MessageName(pb)]
+	if !ok {
+		return data, nil
+	}
+
+	return s.Encode(data)
+}
@@ -0,0 +1,96 @@
+package tp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dairaga/sawtk/client"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/setting_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+// upgradeEntry upgrades Setting_Entry by appending "!" to value.
+func upgradeEntry(data []byte) ([]byte, error) {
+	e := new(setting_pb2.Setting_Entry)
+	if err := proto.Unmarshal(data, e); err != nil {
+		return nil, err
+	}
+	e.Value += "!"
+	return proto.Marshal(e)
+}
+
+func newEntrySchema() *Schema {
+	return NewSchema(new(setting_pb2.Setting_Entry), 2).
+		Upgrade(0, upgradeEntry).
+		Upgrade(1, upgradeEntry)
+}
+
+func TestMigration(t *testing.T) {
+	s := newEntrySchema().WriteBack(true)
+
+	old, _ := proto.Marshal(&setting_pb2.Setting_Entry{Key: "a", Value: "v0"})
+	state := newCountState()
+	state.data["a"] = old
+
+	ctx := newContext(state, &transaction_pb2.TransactionHeader{}, 1)
+	ctx.schemas = map[string]*Schema{s.Name(): s}
+
+	got := new(setting_pb2.Setting_Entry)
+	if ok, err := ctx.Get("a", got); err != nil || !ok {
+		t.Fatalf("get a: %v %v", ok, err)
+	}
+
+	if got.Value != "v0!!" {
+		t.Errorf("value want v0!!, but %q", got.Value)
+	}
+
+	if err := ctx.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, v := s.Unwrap(state.data["a"])
+	if v != 2 {
+		t.Fatalf("record must be written back in version 2, but %d", v)
+	}
+
+	if _, upgraded, _ := s.Decode(state.data["a"]); upgraded {
+		t.Errorf("record in current version must not be upgraded: %v", data)
+	}
+
+	if _, _, err := NewSchema(new(setting_pb2.Setting_Entry), 3).Decode(old); err == nil {
+		t.Error("missing upgrader must fail")
+	}
+}
+
+func TestScanMigrations(t *testing.T) {
+	s := newEntrySchema()
+
+	old, _ := proto.Marshal(&setting_pb2.Setting_Entry{Key: "a"})
+	current, _ := s.Encode(old)
+	other, _ := NewSchemaName("other", 1).Encode(old)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("address") != "abcdef" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		b64 := base64.StdEncoding.EncodeToString
+		fmt.Fprintf(w, `{"data": [{"address": "a", "data": %q}, {"address": "b", "data": %q}, {"address": "c", "data": %q}]}`, b64(old), b64(current), b64(other))
+	}))
+	defer srv.Close()
+
+	infos, err := ScanMigrations(client.New(srv.URL, time.Second), "abcdef", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 1 || infos[0].Address != "a" || infos[0].Version != 0 {
+		t.Errorf("only a needs migration, but %v", infos)
+	}
+}
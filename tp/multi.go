@@ -0,0 +1,68 @@
+package tp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+)
+
+// multiPrefix is format of message prefix of failing command in multi-command request.
+const multiPrefix = "multi-command %d (cmd %d): "
+
+var multiIndex = regexp.MustCompile(`^multi-command (\d+) \(cmd -?\d+\): `)
+
+// NewMultiTPRequest returns a request carrying requests executed in order in one transaction.
+// State changes of all requests are rolled back if any request fails.
+func NewMultiTPRequest(reqs ...*TPRequest) *TPRequest {
+	return &TPRequest{Requests: reqs}
+}
+
+// Add appends a command and data into multi-command request.
+func (r *TPRequest) Add(cmd int32, data proto.Message) error {
+	req, err := NewTPRequest(cmd, data)
+	if err != nil {
+		return err
+	}
+
+	r.Requests = append(r.Requests, req)
+	return nil
+}
+
+// FailedIndex returns index of failing command in message of multi-command request error.
+func FailedIndex(msg string) (int, bool) {
+	m := multiIndex.FindStringSubmatch(msg)
+	if m == nil {
+		return 0, false
+	}
+
+	i, err := strconv.Atoi(m[1])
+	return i, err == nil
+}
+
+// handleMulti executes requests in order with the same context.
+// Error of failing request has its index in message, and keeps its ErrCode.
+func (h *Handler) handleMulti(ctx *Context, version string, reqs []*TPRequest) *processor.InvalidTransactionError {
+	for i, r := range reqs {
+		var err *processor.InvalidTransactionError
+
+		if len(r.Requests) > 0 {
+			err = BadParameters.TxErrorf("nested multi-command request")
+		} else if hfunc, ok := h.route(version, r.Cmd); !ok {
+			err = UnknownCmd.TxErrorf("unknow cmd: %d", r.Cmd)
+		} else {
+			ctx.cmd = r.Cmd
+			err = hfunc(ctx, r)
+		}
+
+		if err != nil {
+			return &processor.InvalidTransactionError{
+				Msg:          fmt.Sprintf(multiPrefix, i, r.Cmd) + err.Msg,
+				ExtendedData: err.ExtendedData,
+			}
+		}
+	}
+	return nil
+}
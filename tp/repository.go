@@ -0,0 +1,170 @@
+package tp
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/dairaga/sawtk/ns"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+)
+
+// Repository stores one type of protobuf messages in a namespace.
+// Address of message is made from key returned by key function.
+type Repository struct {
+	namespace ns.Namespace
+	factory   func() proto.Message
+	key       func(proto.Message) string
+	typ       reflect.Type
+}
+
+// NewRepository returns a repository.
+// factory returns a new empty message, and key returns key of a message.
+func NewRepository(namespace ns.Namespace, factory func() proto.Message, key func(proto.Message) string) *Repository {
+	return &Repository{
+		namespace: namespace,
+		factory:   factory,
+		key:       key,
+		typ:       reflect.TypeOf(factory()),
+	}
+}
+
+func (r *Repository) String() string {
+	return fmt.Sprintf(`{"namespace": "%s", "type": "%s"}`, r.namespace.Prefix(), proto.MessageName(r.factory()))
+}
+
+// Namespace returns namespace of repository.
+func (r *Repository) Namespace() ns.Namespace {
+	return r.namespace
+}
+
+// Address returns address of key.
+func (r *Repository) Address(key string) string {
+	return r.namespace.MakeAddress(key)
+}
+
+// addressOf returns address of message.
+func (r *Repository) addressOf(m proto.Message) (string, *processor.InvalidTransactionError) {
+	if reflect.TypeOf(m) != r.typ {
+		return "", Internal.TxErrorf("repository of %v can not store %T", r.typ, m)
+	}
+	return r.Address(r.key(m)), nil
+}
+
+// ----------------------------------------------------------------------------
+
+// Load returns message of key, and false if not found.
+func (r *Repository) Load(ctx *Context, key string) (proto.Message, bool, *processor.InvalidTransactionError) {
+	m := r.factory()
+	ok, err := ctx.Get(r.Address(key), m)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return m, true, nil
+}
+
+// MustLoad returns message of key, or NotFound error if not found.
+func (r *Repository) MustLoad(ctx *Context, key string) (proto.Message, *processor.InvalidTransactionError) {
+	m, ok, err := r.Load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, NotFound.TxErrorf("%s not found: %s", proto.MessageName(r.factory()), key)
+	}
+	return m, nil
+}
+
+// Exists returns message of key exists or not.
+func (r *Repository) Exists(ctx *Context, key string) (bool, *processor.InvalidTransactionError) {
+	return ctx.Get(r.Address(key), nil)
+}
+
+// LoadAll returns messages of keys. Keys not found are not in result.
+func (r *Repository) LoadAll(ctx *Context, keys ...string) (map[string]proto.Message, *processor.InvalidTransactionError) {
+	data := make(map[string]proto.Message, len(keys))
+	addrs := make(map[string]string, len(keys))
+
+	for _, k := range keys {
+		addr := r.Address(k)
+		data[addr] = r.factory()
+		addrs[addr] = k
+	}
+
+	if err := ctx.GetAll(data); err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]proto.Message, len(data))
+	for addr, m := range data {
+		ret[addrs[addr]] = m
+	}
+	return ret, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// Save stores messages.
+func (r *Repository) Save(ctx *Context, ms ...proto.Message) *processor.InvalidTransactionError {
+	data := make(map[string]proto.Message, len(ms))
+	for _, m := range ms {
+		addr, err := r.addressOf(m)
+		if err != nil {
+			return err
+		}
+		data[addr] = m
+	}
+
+	return ctx.SetAll(data)
+}
+
+// Create stores a new message, or returns Conflict error if it exists.
+func (r *Repository) Create(ctx *Context, m proto.Message) *processor.InvalidTransactionError {
+	addr, err := r.addressOf(m)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := ctx.Get(addr, nil); err != nil {
+		return err
+	} else if ok {
+		return Conflict.TxErrorf("%s exists: %s", proto.MessageName(m), r.key(m))
+	}
+
+	return ctx.Set(addr, m)
+}
+
+// Update stores an existing message, or returns NotFound error if it does not exist.
+func (r *Repository) Update(ctx *Context, m proto.Message) *processor.InvalidTransactionError {
+	addr, err := r.addressOf(m)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := ctx.Get(addr, nil); err != nil {
+		return err
+	} else if !ok {
+		return NotFound.TxErrorf("%s not found: %s", proto.MessageName(m), r.key(m))
+	}
+
+	return ctx.Set(addr, m)
+}
+
+// Delete removes message of key, or returns NotFound error if it does not exist.
+func (r *Repository) Delete(ctx *Context, key string) *processor.InvalidTransactionError {
+	addr := r.Address(key)
+	if ok, err := ctx.Get(addr, nil); err != nil {
+		return err
+	} else if !ok {
+		return NotFound.TxErrorf("%s not found: %s", proto.MessageName(r.factory()), key)
+	}
+
+	if _, err := ctx.Del([]string{addr}); err != nil {
+		if txErr, ok := err.(*processor.InvalidTransactionError); ok {
+			return txErr
+		}
+		return SetState.TxErrore(err)
+	}
+	return nil
+}
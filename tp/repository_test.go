@@ -0,0 +1,58 @@
+package tp
+
+import (
+	"testing"
+
+	"github.com/dairaga/sawtk/ns"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/setting_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+func TestRepository(t *testing.T) {
+	repo := NewRepository(ns.New("repotest"),
+		func() proto.Message { return new(setting_pb2.Setting_Entry) },
+		func(m proto.Message) string { return m.(*setting_pb2.Setting_Entry).Key },
+	)
+
+	ctx := newContext(newCountState(), &transaction_pb2.TransactionHeader{SignerPublicKey: "signer"}, 1)
+
+	a := &setting_pb2.Setting_Entry{Key: "a", Value: "1"}
+	if err := repo.Create(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Create(ctx, a); err == nil || ToErrCode(err.ExtendedData) != Conflict {
+		t.Fatalf("create twice want %v, but %v", Conflict, err)
+	}
+
+	if err := repo.Update(ctx, &setting_pb2.Setting_Entry{Key: "b"}); err == nil || ToErrCode(err.ExtendedData) != NotFound {
+		t.Fatalf("update missing want %v, but %v", NotFound, err)
+	}
+
+	if err := repo.Save(ctx, &setting_pb2.Setting{}); err == nil || ToErrCode(err.ExtendedData) != Internal {
+		t.Fatalf("save other type want %v, but %v", Internal, err)
+	}
+
+	m, err := repo.MustLoad(ctx, "a")
+	if err != nil || !proto.Equal(m, a) {
+		t.Fatalf("must load a: %v %v", m, err)
+	}
+
+	all, err := repo.LoadAll(ctx, "a", "b")
+	if err != nil || len(all) != 1 || all["a"] == nil {
+		t.Fatalf("load all: %v %v", all, err)
+	}
+
+	if err := repo.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, _ := repo.Exists(ctx, "a"); ok {
+		t.Fatal("a must be deleted")
+	}
+
+	if _, err := repo.MustLoad(ctx, "a"); err == nil || ToErrCode(err.ExtendedData) != NotFound {
+		t.Fatalf("must load deleted want %v, but %v", NotFound, err)
+	}
+}
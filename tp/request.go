@@ -36,13 +36,14 @@ func NewTPRequestBytes(cmd int32, data proto.Message) ([]byte, error) {
 }
 
 // UnmarshalTPRequest returns data and command in TPReqest.
+// Data are decoded with encoding in TPRequest.
 func UnmarshalTPRequest(data []byte, pb proto.Message) (int32, error) {
 	req := new(TPRequest)
 	if err := proto.Unmarshal(data, req); err != nil {
 		return 0, err
 	}
 
-	if err := proto.Unmarshal(req.Payload, pb); err != nil {
+	if err := req.Unmarshal(pb); err != nil {
 		return 0, err
 	}
 
@@ -0,0 +1,344 @@
+package tp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dairaga/log"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/processor_pb2"
+)
+
+// RunnerState is state of runner.
+type RunnerState int32
+
+// Runner states.
+const (
+	Stopped RunnerState = iota
+	Registering
+	Registered
+	Backoff
+)
+
+var runnerStates = [...]string{"stopped", "registering", "registered", "backoff"}
+
+func (s RunnerState) String() string {
+	if int(s) < len(runnerStates) {
+		return runnerStates[s]
+	}
+	return fmt.Sprintf("RunnerState(%d)", s)
+}
+
+// RunnerStatus is registration status of runner.
+type RunnerStatus struct {
+	State    RunnerState
+	Handlers []string // family/version
+	Attempts int      // failed attempts since last registration
+	Since    time.Time
+	Err      error
+}
+
+func (s RunnerStatus) String() string {
+	return fmt.Sprintf(`{"state": "%v", "handlers": ["%s"], "attempts": %d, "since": "%s", "error": "%v"}`,
+		s.State, strings.Join(s.Handlers, `", "`), s.Attempts, s.Since.Format(time.RFC3339), s.Err)
+}
+
+// ----------------------------------------------------------------------------
+
+// marker is a handler without versions added before and after handlers of runner.
+// Transaction processor asks versions of handlers one by one when registering,
+// so versions of first marker are asked when registration begins,
+// and versions of last marker are asked after all handlers are registered.
+type marker struct {
+	f func()
+}
+
+func (m *marker) FamilyName() string {
+	return ""
+}
+
+func (m *marker) FamilyVersions() []string {
+	m.f()
+	return nil
+}
+
+func (m *marker) Namespaces() []string {
+	return nil
+}
+
+func (m *marker) Apply(*processor_pb2.TpProcessRequest, *processor.Context) error {
+	return Internal.TxErrorf("marker can not apply transactions")
+}
+
+// ----------------------------------------------------------------------------
+
+// observed is a handler recording result of every request into metrics,
+// including requests failed before or after handler functions.
+type observed struct {
+	*Handler
+	metrics *Metrics
+}
+
+func (o *observed) Apply(req *processor_pb2.TpProcessRequest, ctx *processor.Context) error {
+	return o.Handle(req, ctx)
+}
+
+func (o *observed) Handle(req *processor_pb2.TpProcessRequest, ctx State) error {
+	start := time.Now()
+	cmd, err := o.handle(req, ctx)
+	o.metrics.Record(req.GetHeader().GetFamilyName(), req.GetHeader().GetFamilyVersion(), cmd, time.Since(start), err)
+	return err
+}
+
+// ----------------------------------------------------------------------------
+
+// Runner runs handlers in a Sawtooth Transaction Processor.
+// It restarts the processor with backoff if connecting or registering fails.
+type Runner struct {
+	endpoint   string
+	handlers   []*Handler
+	maxQueue   uint
+	threads    uint
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	httpAddr   string
+	metrics    *Metrics
+
+	mux    sync.Mutex
+	status RunnerStatus
+	proc   *processor.TransactionProcessor
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewRunner returns a runner connecting to validator endpoint.
+func NewRunner(endpoint string) *Runner {
+	return &Runner{
+		endpoint:   endpoint,
+		minBackoff: time.Second,
+		maxBackoff: time.Minute,
+		metrics:    NewMetrics(),
+		status: RunnerStatus{
+			State: Stopped,
+			Since: time.Now(),
+		},
+	}
+}
+
+// Add adds handlers. Requests of handlers are recorded in metrics of runner.
+func (r *Runner) Add(handlers ...*Handler) *Runner {
+	r.handlers = append(r.handlers, handlers...)
+	return r
+}
+
+// Metrics returns metrics of handlers.
+func (r *Runner) Metrics() *Metrics {
+	return r.metrics
+}
+
+// ListenHTTP sets address of HTTP server serving health and metrics while running.
+func (r *Runner) ListenHTTP(addr string) *Runner {
+	r.httpAddr = addr
+	return r
+}
+
+// MaxQueueSize sets max size of work queue. 0 means default of SDK.
+func (r *Runner) MaxQueueSize(n uint) *Runner {
+	r.maxQueue = n
+	return r
+}
+
+// ThreadCount sets number of worker threads. 0 means default of SDK.
+func (r *Runner) ThreadCount(n uint) *Runner {
+	r.threads = n
+	return r
+}
+
+// Backoff sets min and max delay before restarting.
+// Delay doubles after each failed attempt.
+func (r *Runner) Backoff(min, max time.Duration) *Runner {
+	r.minBackoff = min
+	r.maxBackoff = max
+	return r
+}
+
+// Status returns registration status.
+func (r *Runner) Status() RunnerStatus {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.status
+}
+
+func (r *Runner) setState(state RunnerState, err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	switch {
+	case state == Registered:
+		r.status.Attempts = 0
+	case err != nil:
+		r.status.Attempts++
+	}
+
+	r.status.State = state
+	r.status.Err = err
+	r.status.Since = time.Now()
+}
+
+// ----------------------------------------------------------------------------
+
+// newProcessor returns a transaction processor with handlers between markers.
+func (r *Runner) newProcessor() *processor.TransactionProcessor {
+	proc := processor.NewTransactionProcessor(r.endpoint)
+	if r.maxQueue > 0 {
+		proc.SetMaxQueueSize(r.maxQueue)
+	}
+	if r.threads > 0 {
+		proc.SetThreadCount(r.threads)
+	}
+
+	proc.AddHandler(&marker{func() { r.setState(Registering, nil) }})
+	for _, h := range r.handlers {
+		proc.AddHandler(&observed{Handler: h, metrics: r.metrics})
+	}
+	proc.AddHandler(&marker{func() {
+		r.setState(Registered, nil)
+		log.Infof("%d handlers registered", len(r.handlers))
+	}})
+	return proc
+}
+
+// backoff returns delay before restarting after failed attempts.
+func (r *Runner) backoff(attempts int) time.Duration {
+	delay := r.minBackoff
+	for i := 1; i < attempts && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > r.maxBackoff {
+		delay = r.maxBackoff
+	}
+	return delay
+}
+
+// stopped returns runner is stopping or not.
+func (r *Runner) stopped() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run starts transaction processor and blocks until runner is stopped.
+func (r *Runner) Run() error {
+	if len(r.handlers) <= 0 {
+		return fmt.Errorf("runner has no handlers")
+	}
+
+	r.mux.Lock()
+	if r.done != nil {
+		r.mux.Unlock()
+		return fmt.Errorf("runner is running")
+	}
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	r.status.Handlers = nil
+	for _, h := range r.handlers {
+		for _, v := range h.FamilyVersions() {
+			r.status.Handlers = append(r.status.Handlers, h.FamilyName()+"/"+v)
+		}
+	}
+	r.mux.Unlock()
+
+	if r.httpAddr != "" {
+		srv := &http.Server{Addr: r.httpAddr, Handler: r}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Errorf("http server on %s: %v", r.httpAddr, err)
+			}
+		}()
+		defer srv.Close()
+	}
+
+	defer func() {
+		r.mux.Lock()
+		close(r.done)
+		r.done = nil
+		r.mux.Unlock()
+		r.setState(Stopped, nil)
+	}()
+
+	for !r.stopped() {
+		proc := r.newProcessor()
+
+		r.mux.Lock()
+		r.proc = proc
+		r.mux.Unlock()
+
+		err := proc.Start()
+
+		r.mux.Lock()
+		r.proc = nil
+		r.mux.Unlock()
+
+		if r.stopped() {
+			break
+		}
+
+		if err == nil {
+			// processor shutdown without restarting.
+			continue
+		}
+
+		r.setState(Backoff, err)
+		delay := r.backoff(r.Status().Attempts)
+		log.Warnf("restart processor in %v: %v", delay, err)
+
+		select {
+		case <-r.stop:
+		case <-time.After(delay):
+		}
+	}
+
+	return nil
+}
+
+// Stop shuts down transaction processor and waits until Run returns or ctx is done.
+func (r *Runner) Stop(ctx context.Context) error {
+	r.mux.Lock()
+	done := r.done
+	if done == nil {
+		r.mux.Unlock()
+		return nil
+	}
+	if !r.stopped() {
+		close(r.stop)
+	}
+	r.mux.Unlock()
+
+	// processor ignores shutdown before it connects, so send it again until Run returns.
+	tick := time.NewTicker(100 * time.Millisecond)
+	defer tick.Stop()
+
+	for {
+		r.mux.Lock()
+		if r.proc != nil {
+			r.proc.Shutdown()
+		}
+		r.mux.Unlock()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package tp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunner(t *testing.T) {
+	r := NewRunner("invalid").
+		Add(NewHandler(NewFamily("runnertest", []string{"1.0", "2.0"}, []string{"abcdef"}))).
+		Backoff(time.Millisecond, 5*time.Millisecond)
+
+	errc := make(chan error, 1)
+	go func() { errc <- r.Run() }()
+
+	for i := 0; r.Status().Attempts < 3; i++ {
+		if i > 200 {
+			t.Fatalf("runner must retry: %v", r.Status())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	status := r.Status()
+	if status.State != Backoff || status.Err == nil {
+		t.Fatalf("state want %v with error, but %v", Backoff, status)
+	}
+
+	if len(status.Handlers) != 2 || status.Handlers[1] != "runnertest/2.0" {
+		t.Fatalf("handlers: %v", status.Handlers)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := r.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	if s := r.Status().State; s != Stopped {
+		t.Fatalf("state want %v, but %v", Stopped, s)
+	}
+}
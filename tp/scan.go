@@ -0,0 +1,63 @@
+package tp
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/dairaga/sawtk/client"
+	"github.com/golang/protobuf/proto"
+)
+
+// RecordInfo describes schema version of a record in state.
+type RecordInfo struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Version uint32 `json:"version"`
+	Current uint32 `json:"current"`
+}
+
+func (r *RecordInfo) String() string {
+	return fmt.Sprintf(`{"address": "%s", "type": "%s", "version": %d, "current": %d}`, r.Address, r.Type, r.Version, r.Current)
+}
+
+// ScanMigrations scans states under prefix through sawtooth restful api,
+// and returns records of schema older than current version.
+// Records without Record under prefix are treated as version 0 of schema.
+func ScanMigrations(cli *client.Client, prefix string, s *Schema) ([]*RecordInfo, error) {
+	var ret []*RecordInfo
+
+	resp, err := cli.States("", prefix, "", 0, "")
+	for {
+		if err != nil {
+			return nil, err
+		}
+
+		for _, x := range resp.Data {
+			raw, err := base64.StdEncoding.DecodeString(x.Data)
+			if err != nil {
+				return nil, fmt.Errorf("state %s: %v", x.Address, err)
+			}
+
+			r := new(Record)
+			if proto.Unmarshal(raw, r) == nil && r.Version > 0 && r.Type != s.name {
+				// record of other type.
+				continue
+			}
+
+			if _, v := s.Unwrap(raw); v < s.version {
+				ret = append(ret, &RecordInfo{
+					Address: x.Address,
+					Type:    s.name,
+					Version: v,
+					Current: s.version,
+				})
+			}
+		}
+
+		if resp.Paging == nil || resp.Paging.Next == "" {
+			return ret, nil
+		}
+
+		resp, err = cli.StatesWithURL(resp.Paging.Next)
+	}
+}
@@ -0,0 +1,142 @@
+package tp
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dairaga/sawtk/ns"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/setting_pb2"
+)
+
+// SettingDecoder decodes value of a setting.
+type SettingDecoder func(value string) (interface{}, error)
+
+// settingEntry is a decoded setting cached in context.
+type settingEntry struct {
+	found bool
+	value interface{}
+}
+
+// ----------------------------------------------------------------------------
+
+// rawSetting returns value of setting key in state.
+func (ctx *Context) rawSetting(key string) (string, bool, *processor.InvalidTransactionError) {
+	setting := new(setting_pb2.Setting)
+	if ok, err := ctx.Get(ns.Settings().MakeAddress(key), setting); err != nil || !ok {
+		return "", false, err
+	}
+
+	for _, x := range setting.Entries {
+		if x.Key == key {
+			return x.Value, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// SettingValue returns value of setting key decoded by decode, or def if not found.
+// Decoded values are cached in ctx only. Transactions in the same block may change settings,
+// and validator may apply them in parallel or again in another order, so values cached across
+// contexts of a block could differ from states seen by each transaction, and break determinism.
+// kind distinguishes decoders of the same key.
+func (ctx *Context) SettingValue(key, kind string, decode SettingDecoder, def interface{}) (interface{}, *processor.InvalidTransactionError) {
+	cacheKey := kind + ":" + key
+	_, changed := ctx.changes[ns.Settings().MakeAddress(key)]
+
+	if !changed {
+		if e, ok := ctx.settings[cacheKey]; ok {
+			if !e.found {
+				return def, nil
+			}
+			return e.value, nil
+		}
+	}
+
+	raw, found, err := ctx.rawSetting(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if found {
+		v, err := decode(raw)
+		if err != nil {
+			return nil, Internal.TxErrorf("setting %s: %v", key, err)
+		}
+		value = v
+	}
+
+	if !changed {
+		if ctx.settings == nil {
+			ctx.settings = make(map[string]settingEntry)
+		}
+		ctx.settings[cacheKey] = settingEntry{found: found, value: value}
+	}
+
+	if !found {
+		return def, nil
+	}
+	return value, nil
+}
+
+// SettingString returns value of setting key, or def if not found.
+func (ctx *Context) SettingString(key, def string) (string, *processor.InvalidTransactionError) {
+	v, err := ctx.SettingValue(key, "string", func(s string) (interface{}, error) { return s, nil }, def)
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// SettingInt returns value of setting key in integer, or def if not found.
+func (ctx *Context) SettingInt(key string, def int64) (int64, *processor.InvalidTransactionError) {
+	v, err := ctx.SettingValue(key, "int", func(s string) (interface{}, error) {
+		return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	}, def)
+	if err != nil {
+		return 0, err
+	}
+	return v.(int64), nil
+}
+
+// SettingBool returns value of setting key in boolean, or def if not found.
+func (ctx *Context) SettingBool(key string, def bool) (bool, *processor.InvalidTransactionError) {
+	v, err := ctx.SettingValue(key, "bool", func(s string) (interface{}, error) {
+		return strconv.ParseBool(strings.TrimSpace(s))
+	}, def)
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// SettingDuration returns value of setting key in duration, ex: 10s, or def if not found.
+func (ctx *Context) SettingDuration(key string, def time.Duration) (time.Duration, *processor.InvalidTransactionError) {
+	v, err := ctx.SettingValue(key, "duration", func(s string) (interface{}, error) {
+		return time.ParseDuration(strings.TrimSpace(s))
+	}, def)
+	if err != nil {
+		return 0, err
+	}
+	return v.(time.Duration), nil
+}
+
+// SettingStrings returns comma separated values of setting key, or def if not found.
+// Returned slice is a copy, and callers can modify it.
+func (ctx *Context) SettingStrings(key string, def []string) ([]string, *processor.InvalidTransactionError) {
+	v, err := ctx.SettingValue(key, "strings", func(s string) (interface{}, error) {
+		var ret []string
+		for _, x := range strings.Split(s, ",") {
+			if x = strings.TrimSpace(x); x != "" {
+				ret = append(ret, x)
+			}
+		}
+		return ret, nil
+	}, def)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), v.([]string)...), nil
+}
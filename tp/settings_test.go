@@ -0,0 +1,104 @@
+package tp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dairaga/sawtk/ns"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/setting_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+func putSetting(t *testing.T, s *countState, key, value string) {
+	data, err := proto.Marshal(&setting_pb2.Setting{Entries: []*setting_pb2.Setting_Entry{{Key: key, Value: value}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.data[ns.Settings().MakeAddress(key)] = data
+}
+
+func TestContextSettings(t *testing.T) {
+	state := newCountState()
+	putSetting(t, state, "sawtk.test.max", "10")
+	putSetting(t, state, "sawtk.test.timeout", "3s")
+	putSetting(t, state, "sawtk.test.admins", "a, b")
+	putSetting(t, state, "sawtk.test.bad", "x")
+
+	newCtx := func() *Context {
+		return newContext(state, &transaction_pb2.TransactionHeader{}, 1)
+	}
+
+	ctx := newCtx()
+	if v, err := ctx.SettingInt("sawtk.test.max", 1); err != nil || v != 10 {
+		t.Fatalf("max want 10, but %v %v", v, err)
+	}
+
+	if v, err := ctx.SettingDuration("sawtk.test.timeout", 0); err != nil || v != 3*time.Second {
+		t.Fatalf("timeout want 3s, but %v %v", v, err)
+	}
+
+	if v, err := ctx.SettingStrings("sawtk.test.admins", nil); err != nil || len(v) != 2 || v[1] != "b" {
+		t.Fatalf("admins want [a b], but %v %v", v, err)
+	}
+
+	if v, err := ctx.SettingBool("sawtk.test.missing", true); err != nil || !v {
+		t.Fatalf("missing want default, but %v %v", v, err)
+	}
+
+	if _, err := ctx.SettingInt("sawtk.test.bad", 0); err == nil || ToErrCode(err.ExtendedData) != Internal {
+		t.Fatalf("bad want %v, but %v", Internal, err)
+	}
+
+	admins, _ := ctx.SettingStrings("sawtk.test.admins", nil)
+	admins[0] = "x"
+	if v, _ := ctx.SettingStrings("sawtk.test.admins", nil); v[0] != "a" {
+		t.Fatalf("cached values must be copied, but %v", v)
+	}
+
+	putSetting(t, state, "sawtk.test.max", "20")
+	gets := state.gets
+
+	if v, _ := ctx.SettingInt("sawtk.test.max", 1); v != 10 || state.gets != gets {
+		t.Fatalf("same context must use cache, but %d with %d gets", v, state.gets-gets)
+	}
+
+	if v, _ := newCtx().SettingInt("sawtk.test.max", 1); v != 20 {
+		t.Fatalf("other context want 20, but %d", v)
+	}
+
+	setting := &setting_pb2.Setting{Entries: []*setting_pb2.Setting_Entry{{Key: "sawtk.test.max", Value: "30"}}}
+	if err := ctx.Set(ns.Settings().MakeAddress("sawtk.test.max"), setting); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := ctx.SettingInt("sawtk.test.max", 1); v != 30 {
+		t.Fatalf("changed setting want 30, but %d", v)
+	}
+}
+
+func TestSettingsInBlock(t *testing.T) {
+	state := newCountState() // states of a block.
+	putSetting(t, state, "sawtk.test.max", "10")
+	header := &transaction_pb2.TransactionHeader{}
+
+	tx1 := newContext(state, header, 1)
+	if v, _ := tx1.SettingInt("sawtk.test.max", 1); v != 10 {
+		t.Fatalf("tx1 want 10, but %d", v)
+	}
+
+	setting := &setting_pb2.Setting{Entries: []*setting_pb2.Setting_Entry{{Key: "sawtk.test.max", Value: "20"}}}
+	if err := tx1.Set(ns.Settings().MakeAddress("sawtk.test.max"), setting); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx1.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx2 := newContext(state, header, 1)
+	if v, _ := tx2.SettingInt("sawtk.test.max", 1); v != 20 {
+		t.Fatalf("tx2 must not use values cached by tx1 in the same block, but %d", v)
+	}
+	if len(tx2.settings) != 1 || len(tx1.settings) != 1 {
+		t.Fatalf("caches of contexts: %v, %v", tx1.settings, tx2.settings)
+	}
+}
@@ -0,0 +1,219 @@
+package tp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/dairaga/sawtk/client"
+	"github.com/dairaga/sawtk/tx"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/processor_pb2"
+)
+
+// SimEvent is an event added by handler in simulation.
+type SimEvent struct {
+	Type       string
+	Attributes []processor.Attribute
+	Data       []byte
+}
+
+// Simulation is result of running a transaction without submitting it.
+type Simulation struct {
+	Head     string            // block id which state is read at.
+	Err      error             // error returned by handler, nil means transaction would be valid.
+	Reads    map[string][]byte // states read from chain, nil means not found.
+	Writes   map[string][]byte // states written by handler, nil means deleted.
+	Events   []SimEvent
+	Receipts [][]byte
+}
+
+func (s *Simulation) String() string {
+	return fmt.Sprintf(`{"head": "%s", "error": "%v", "reads": %d, "writes": %d, "events": %d, "receipts": %d}`,
+		s.Head, s.Err, len(s.Reads), len(s.Writes), len(s.Events), len(s.Receipts))
+}
+
+// ----------------------------------------------------------------------------
+
+// chainState implements State by reading states from restful api at head.
+// Writes, events and receipts are only recorded.
+type chainState struct {
+	cli *client.Client
+	sim *Simulation
+	err error // first error from restful api.
+}
+
+func (s *chainState) fetch(address string) ([]byte, error) {
+	entry, err := s.cli.State(address, s.sim.Head)
+	if err != nil {
+		if x, ok := err.(*client.Error); ok && x.HTTPStatusCode() == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(entry.Data)
+}
+
+func (s *chainState) GetState(addresses []string) (map[string][]byte, error) {
+	ret := make(map[string][]byte)
+	for _, x := range addresses {
+		if data, ok := s.sim.Writes[x]; ok {
+			if len(data) > 0 {
+				ret[x] = data
+			}
+			continue
+		}
+
+		data, ok := s.sim.Reads[x]
+		if !ok {
+			var err error
+			if data, err = s.fetch(x); err != nil {
+				if s.err == nil {
+					s.err = fmt.Errorf("state %s: %v", x, err)
+				}
+				return nil, s.err
+			}
+			s.sim.Reads[x] = data
+		}
+
+		if len(data) > 0 {
+			ret[x] = data
+		}
+	}
+	return ret, nil
+}
+
+func (s *chainState) SetState(pairs map[string][]byte) ([]string, error) {
+	ret := make([]string, 0, len(pairs))
+	for k, v := range pairs {
+		s.sim.Writes[k] = v
+		ret = append(ret, k)
+	}
+	return ret, nil
+}
+
+func (s *chainState) DeleteState(addresses []string) ([]string, error) {
+	for _, x := range addresses {
+		s.sim.Writes[x] = nil
+	}
+	return addresses, nil
+}
+
+func (s *chainState) AddReceiptData(data []byte) error {
+	s.sim.Receipts = append(s.sim.Receipts, data)
+	return nil
+}
+
+func (s *chainState) AddEvent(typ string, attributes []processor.Attribute, data []byte) error {
+	s.sim.Events = append(s.sim.Events, SimEvent{Type: typ, Attributes: attributes, Data: data})
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+
+// Simulator runs transactions with handler in process against chain state,
+// which is fetched lazily through restful api at a pinned head.
+// Nothing is submitted to chain.
+//
+// Simulations run a copy of handler without crash reports, and are not recorded in metrics of runner.
+// Middlewares of handler still run.
+type Simulator struct {
+	handler *Handler
+	cli     *client.Client
+
+	mux  sync.Mutex
+	head string
+}
+
+// NewSimulator returns a simulator of handler reading states through cli.
+func NewSimulator(h *Handler, cli *client.Client) *Simulator {
+	return &Simulator{
+		handler: h,
+		cli:     cli,
+	}
+}
+
+// At pins block id which states are read at.
+// Without pinning, head is the latest block when the first transaction runs.
+func (s *Simulator) At(head string) *Simulator {
+	s.mux.Lock()
+	s.head = head
+	s.mux.Unlock()
+	return s
+}
+
+// Head returns pinned block id, and pins the latest block if not pinned.
+func (s *Simulator) Head() (string, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.head != "" {
+		return s.head, nil
+	}
+
+	resp, err := s.cli.Blocks("", "", 1, "")
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case resp.Head != "":
+		s.head = resp.Head
+	case len(resp.Data) > 0:
+		s.head = resp.Data[0].HeaderSignature
+	default:
+		return "", fmt.Errorf("head block not found")
+	}
+	return s.head, nil
+}
+
+// Run runs transaction data signed by signer public key.
+// Rejection of handler is returned in Simulation.Err,
+// and error is returned only if states can not be read from chain.
+func (s *Simulator) Run(signer string, data *tx.Data) (*Simulation, error) {
+	head, err := s.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	sim := &Simulation{
+		Head:   head,
+		Reads:  make(map[string][]byte),
+		Writes: make(map[string][]byte),
+	}
+	state := &chainState{cli: s.cli, sim: sim}
+
+	// panics in simulations are returned in Simulation.Err only.
+	sandbox := *s.handler
+	sandbox.crashDir = ""
+	err = sandbox.Handle(&processor_pb2.TpProcessRequest{
+		Header:  data.TxHeader(signer, signer),
+		Payload: data.Payload(),
+	}, state)
+
+	if state.err != nil {
+		return nil, state.err
+	}
+
+	if err != nil {
+		// validator drops events and receipts of invalid transactions.
+		sim.Events = nil
+		sim.Receipts = nil
+	}
+	sim.Err = err
+	return sim, nil
+}
+
+// DryRun runs a command and message with handler against chain state without submitting.
+// Transaction is built like Submit, so it checks the same inputs and outputs.
+func (fc *FamilyClient) DryRun(h *Handler, cmd int32, msg proto.Message, inputs, outputs []string) (*Simulation, error) {
+	data, err := fc.Data(cmd, msg, inputs, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSimulator(h, fc.cli).Run(fc.txb.Signer().GetPublicKey().AsHex(), data)
+}
@@ -0,0 +1,142 @@
+package tp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dairaga/sawtk/client"
+	"github.com/dairaga/sawtk/ns"
+	"github.com/dairaga/sawtk/signing"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/setting_pb2"
+)
+
+func TestSimulator(t *testing.T) {
+	myns := ns.New("simtest")
+	src, dst := myns.MakeAddress("src"), myns.MakeAddress("dst")
+
+	data, _ := proto.Marshal(&setting_pb2.Setting_Entry{Key: "k", Value: "v"})
+	heads := make(map[string]bool)
+	fetched := 0
+	failing := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case failing:
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"code": 10, "title": "Unknown"}`)
+		case r.URL.Path == "/blocks":
+			fmt.Fprint(w, `{"head": "head1", "data": [{"header_signature": "head1"}]}`)
+		case r.URL.Path == "/state/"+src:
+			fetched++
+			heads[r.URL.Query().Get("head")] = true
+			fmt.Fprintf(w, `{"data": %q, "head": "head1"}`, base64.StdEncoding.EncodeToString(data))
+		case strings.HasPrefix(r.URL.Path, "/state/"):
+			fetched++
+			heads[r.URL.Query().Get("head")] = true
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code": 75, "title": "State Not Found"}`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"code": 10, "title": "Unknown"}`)
+		}
+	}))
+	defer srv.Close()
+
+	family := NewFamily("simtest", []string{"1.0"}, []string{myns.Prefix()})
+	h := NewHandler(family)
+	h.Add(1, func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+		entry := new(setting_pb2.Setting_Entry)
+		if ok, err := ctx.Get(src, entry); err != nil || !ok {
+			return NotFound.TxErrorf("src not found: %v", err)
+		}
+		if ok, _ := ctx.Get(dst, nil); ok {
+			return Internal.TxErrorf("dst exists")
+		}
+		if err := ctx.Set(dst, entry); err != nil {
+			return err
+		}
+		if err := ctx.AddEventMessage("simtest/copy", entry); err != nil {
+			return err
+		}
+		return ctx.AddReceiptData(entry)
+	})
+	h.Add(2, func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+		if ok, _ := ctx.Get(dst, nil); !ok {
+			return NotFound.TxErrorf("dst not found")
+		}
+		return nil
+	})
+
+	h.Add(3, func(ctx *Context, req *TPRequest) *processor.InvalidTransactionError {
+		panic("boom")
+	})
+
+	dir, err := ioutil.TempDir("", "simcrash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	h.CrashDir(dir)
+
+	fc := NewFamilyClient(family, myns, signing.GenerateSignerFromCode("sim"), client.New(srv.URL, time.Second))
+
+	sim, err := fc.DryRun(h, 1, nil, []string{src, dst}, []string{dst})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim.Err != nil {
+		t.Fatal(sim.Err)
+	}
+
+	if sim.Head != "head1" || len(heads) != 1 || !heads["head1"] {
+		t.Errorf("head want head1, but %s, read at %v", sim.Head, heads)
+	}
+	if fetched != 2 || len(sim.Reads) != 2 || sim.Reads[dst] != nil || len(sim.Reads[src]) == 0 {
+		t.Errorf("reads: %d %v", fetched, sim.Reads)
+	}
+	if len(sim.Writes) != 1 || len(sim.Writes[dst]) == 0 {
+		t.Errorf("writes: %v", sim.Writes)
+	}
+	if len(sim.Events) != 1 || sim.Events[0].Type != "simtest/copy" || len(sim.Receipts) != 1 {
+		t.Errorf("events: %v, receipts: %v", sim.Events, sim.Receipts)
+	}
+
+	// nothing is written into chain.
+	sim, err = fc.DryRun(h, 2, nil, []string{dst}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim.Err == nil || ToErrCode(sim.Err.(*processor.InvalidTransactionError).ExtendedData) != NotFound {
+		t.Errorf("simulation want %v, but %v", NotFound, sim.Err)
+	}
+
+	// panics are rejections without crash reports.
+	sim, err = fc.DryRun(h, 3, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim.Err == nil || ToErrCode(sim.Err.(*processor.InvalidTransactionError).ExtendedData) != Internal {
+		t.Errorf("panic want %v, but %v", Internal, sim.Err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 || h.crashDir != dir {
+		t.Errorf("simulation must not write crash reports: %d files", len(files))
+	}
+
+	// error from restful api is not a rejection of handler.
+	failing = true
+	txdata, err := fc.Data(2, nil, []string{dst}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSimulator(h, fc.Client()).At("head1").Run("pubkey", txdata); err == nil {
+		t.Error("simulation must fail if state can not be read")
+	}
+}
@@ -0,0 +1,106 @@
+package tptest
+
+import (
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+)
+
+// Event is an event added by handler.
+type Event struct {
+	Type       string
+	Attributes []processor.Attribute
+	Data       []byte
+}
+
+// Attribute returns value of attribute with key.
+func (e *Event) Attribute(key string) (string, bool) {
+	for _, x := range e.Attributes {
+		if x.Key == key {
+			return x.Value, true
+		}
+	}
+	return "", false
+}
+
+// ----------------------------------------------------------------------------
+
+// Context is a fake state context implementing tp.State.
+// Like validator, changes are kept in context and written into store only when committed.
+type Context struct {
+	store    *Store
+	changes  map[string][]byte // nil value means deleted.
+	Events   []Event
+	Receipts [][]byte
+}
+
+// NewContext returns a fake state context on store.
+func NewContext(store *Store) *Context {
+	return &Context{
+		store:   store,
+		changes: make(map[string][]byte),
+	}
+}
+
+// GetState implements tp.State.
+func (c *Context) GetState(addresses []string) (map[string][]byte, error) {
+	ret := make(map[string][]byte)
+	for _, x := range addresses {
+		if data, ok := c.changes[x]; ok {
+			if len(data) > 0 {
+				ret[x] = data
+			}
+			continue
+		}
+
+		if data, ok := c.store.Get(x); ok {
+			ret[x] = data
+		}
+	}
+	return ret, nil
+}
+
+// SetState implements tp.State.
+func (c *Context) SetState(pairs map[string][]byte) ([]string, error) {
+	ret := make([]string, 0, len(pairs))
+	for k, v := range pairs {
+		c.changes[k] = v
+		ret = append(ret, k)
+	}
+	return ret, nil
+}
+
+// DeleteState implements tp.State.
+func (c *Context) DeleteState(addresses []string) ([]string, error) {
+	ret := make([]string, 0, len(addresses))
+	for _, x := range addresses {
+		if data, _ := c.GetState([]string{x}); len(data) > 0 {
+			ret = append(ret, x)
+		}
+		c.changes[x] = nil
+	}
+	return ret, nil
+}
+
+// AddReceiptData implements tp.State.
+func (c *Context) AddReceiptData(data []byte) error {
+	c.Receipts = append(c.Receipts, data)
+	return nil
+}
+
+// AddEvent implements tp.State.
+func (c *Context) AddEvent(typ string, attributes []processor.Attribute, data []byte) error {
+	c.Events = append(c.Events, Event{Type: typ, Attributes: attributes, Data: data})
+	return nil
+}
+
+// Changes returns changed data. Deleted addresses have nil value.
+func (c *Context) Changes() map[string][]byte {
+	return c.changes
+}
+
+// Commit writes changes into store.
+func (c *Context) Commit() {
+	for k, v := range c.changes {
+		c.store.Set(k, v)
+	}
+	c.changes = make(map[string][]byte)
+}
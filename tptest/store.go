@@ -0,0 +1,87 @@
+package tptest
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Store is an in-memory global state.
+type Store struct {
+	mutex sync.RWMutex
+	data  map[string][]byte
+}
+
+// NewStore returns an empty in-memory state.
+func NewStore() *Store {
+	return &Store{data: make(map[string][]byte)}
+}
+
+// Get returns data at address.
+func (s *Store) Get(address string) ([]byte, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	data, ok := s.data[address]
+	return data, ok
+}
+
+// Set puts data at address. Empty data deletes address.
+func (s *Store) Set(address string, data []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(data) <= 0 {
+		delete(s.data, address)
+		return
+	}
+	s.data[address] = data
+}
+
+// Delete removes data at address.
+func (s *Store) Delete(address string) {
+	s.Set(address, nil)
+}
+
+// Load unmarshals data at address into pb.
+func (s *Store) Load(address string, pb proto.Message) (bool, error) {
+	data, ok := s.Get(address)
+	if !ok {
+		return false, nil
+	}
+
+	return true, proto.Unmarshal(data, pb)
+}
+
+// Save marshals pb and puts it at address.
+func (s *Store) Save(address string, pb proto.Message) error {
+	data, err := proto.Marshal(pb)
+	if err != nil {
+		return err
+	}
+
+	s.Set(address, data)
+	return nil
+}
+
+// Addresses returns sorted addresses in state.
+func (s *Store) Addresses() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ret := make([]string, 0, len(s.data))
+	for k := range s.data {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Len returns number of addresses in state.
+func (s *Store) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.data)
+}
@@ -0,0 +1,223 @@
+/*
+Package tptest runs SawTK transaction handlers without a validator.
+
+It provides an in-memory state store, a fake state context recording events
+and receipt data, and a harness invoking tp.Handler with TPRequest.
+*/
+package tptest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dairaga/sawtk/signing"
+	"github.com/dairaga/sawtk/tp"
+	"github.com/dairaga/sawtk/tx"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/processor_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+// Result is result of invoking handler.
+type Result struct {
+	Err      error
+	Events   []Event
+	Receipts [][]byte
+	Changes  map[string][]byte
+}
+
+// OK returns handler succeeds or not.
+func (r *Result) OK() bool {
+	return r.Err == nil
+}
+
+// Code returns ErrCode in InvalidTransactionError, or 0 if no code.
+func (r *Result) Code() tp.ErrCode {
+	err, ok := r.Err.(*processor.InvalidTransactionError)
+	if !ok || err == nil || len(err.ExtendedData) != 4 {
+		return 0
+	}
+	return tp.ToErrCode(err.ExtendedData)
+}
+
+// Event returns first event with type.
+func (r *Result) Event(typ string) (*Event, bool) {
+	for i := range r.Events {
+		if r.Events[i].Type == typ {
+			return &r.Events[i], true
+		}
+	}
+	return nil, false
+}
+
+// AssertOK fails t if handler failed.
+func (r *Result) AssertOK(t testing.TB) {
+	t.Helper()
+	if r.Err != nil {
+		t.Fatalf("handler failed: %v", r.Err)
+	}
+}
+
+// AssertCode fails t if handler did not fail with code.
+func (r *Result) AssertCode(t testing.TB, code tp.ErrCode) {
+	t.Helper()
+	if r.Err == nil {
+		t.Fatalf("handler want %v, but succeeded", code)
+	}
+
+	if c := r.Code(); c != code {
+		t.Fatalf("handler want %v, but %v: %v", code, c, r.Err)
+	}
+}
+
+// AssertEvent fails t if no event with type, and unmarshals event data into pb if pb is not nil.
+func (r *Result) AssertEvent(t testing.TB, typ string, pb proto.Message) *Event {
+	t.Helper()
+	evt, ok := r.Event(typ)
+	if !ok {
+		t.Fatalf("event %s not found", typ)
+	}
+
+	if pb != nil {
+		if err := proto.Unmarshal(evt.Data, pb); err != nil {
+			t.Fatalf("event %s unmarshal: %v", typ, err)
+		}
+	}
+	return evt
+}
+
+// AssertReceipt fails t if no receipt data at index, and unmarshals it into pb.
+func (r *Result) AssertReceipt(t testing.TB, index int, pb proto.Message) {
+	t.Helper()
+	if index >= len(r.Receipts) {
+		t.Fatalf("receipt %d not found, got %d", index, len(r.Receipts))
+	}
+
+	if err := proto.Unmarshal(r.Receipts[index], pb); err != nil {
+		t.Fatalf("receipt %d unmarshal: %v", index, err)
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+// Harness invokes a handler against an in-memory state.
+type Harness struct {
+	Handler *tp.Handler
+	Store   *Store
+	version string
+	inputs  []string
+	outputs []string
+	seq     int // sequence of invocations, used as context id.
+}
+
+// New returns a harness of handler with an empty state.
+func New(h *tp.Handler) *Harness {
+	return &Harness{
+		Handler: h,
+		Store:   NewStore(),
+		version: h.FamilyVersions()[0],
+	}
+}
+
+// Version sets family version in transaction header.
+func (hn *Harness) Version(v string) *Harness {
+	hn.version = v
+	return hn
+}
+
+// Inputs sets inputs in transaction header.
+// Context checks addresses read against inputs if inputs or outputs are set.
+func (hn *Harness) Inputs(addrs ...string) *Harness {
+	hn.inputs = addrs
+	return hn
+}
+
+// Outputs sets outputs in transaction header.
+// Context checks addresses written against outputs if inputs or outputs are set.
+func (hn *Harness) Outputs(addrs ...string) *Harness {
+	hn.outputs = addrs
+	return hn
+}
+
+// InvokeRequest invokes handler with request signed by public key.
+// Changes are written into store only if handler succeeds.
+func (hn *Harness) InvokeRequest(pubkey string, req *tp.TPRequest) *Result {
+	payload, err := req.ToBytes()
+	if err != nil {
+		return &Result{Err: err}
+	}
+
+	hn.seq++
+	ctx := NewContext(hn.Store)
+	err = hn.Handler.Handle(&processor_pb2.TpProcessRequest{
+		Header: &transaction_pb2.TransactionHeader{
+			FamilyName:      hn.Handler.FamilyName(),
+			FamilyVersion:   hn.version,
+			SignerPublicKey: pubkey,
+			Inputs:          hn.inputs,
+			Outputs:         hn.outputs,
+			Nonce:           tx.Nonce(),
+			PayloadSha512:   signing.SHA512(payload),
+		},
+		Payload:   payload,
+		ContextId: fmt.Sprintf("tptest-%d", hn.seq),
+	}, ctx)
+
+	ret := &Result{
+		Err:      err,
+		Events:   ctx.Events,
+		Receipts: ctx.Receipts,
+		Changes:  ctx.Changes(),
+	}
+
+	if err == nil {
+		ctx.Commit()
+	}
+	return ret
+}
+
+// Invoke invokes handler with command and message signed by public key.
+func (hn *Harness) Invoke(pubkey string, cmd int32, msg proto.Message) *Result {
+	req, err := tp.NewTPRequest(cmd, msg)
+	if err != nil {
+		return &Result{Err: err}
+	}
+
+	return hn.InvokeRequest(pubkey, req)
+}
+
+// AssertState fails t if data at address is not equal to want.
+// want is nil means address must not exist.
+func (hn *Harness) AssertState(t testing.TB, address string, want proto.Message) {
+	t.Helper()
+	if want == nil {
+		if _, ok := hn.Store.Get(address); ok {
+			t.Fatalf("state %s want not found, but found", address)
+		}
+		return
+	}
+
+	data, ok := hn.Store.Get(address)
+	if !ok {
+		t.Fatalf("state %s not found", address)
+	}
+
+	if s, ok := hn.Handler.SchemaOf(want); ok {
+		var err error
+		if data, _, err = s.Decode(data); err != nil {
+			t.Fatalf("state %s decode: %v", address, err)
+		}
+	}
+
+	got := proto.Clone(want)
+	got.Reset()
+
+	if err := proto.Unmarshal(data, got); err != nil {
+		t.Fatalf("state %s unmarshal: %v", address, err)
+	}
+
+	if !proto.Equal(got, want) {
+		t.Fatalf("state %s want %v, but %v", address, want, got)
+	}
+}
@@ -0,0 +1,110 @@
+package tptest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dairaga/sawtk/ns"
+	"github.com/dairaga/sawtk/tp"
+	"github.com/dairaga/sawtk/tptest"
+	"github.com/hyperledger/sawtooth-sdk-go/processor"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/setting_pb2"
+)
+
+var myns = ns.New("tptest")
+
+type entry struct {
+	setting_pb2.Setting_Entry
+}
+
+func (e *entry) Validate() *processor.InvalidTransactionError {
+	if e.Key == "" {
+		return tp.BadParameters.TxErrorf("key is required")
+	}
+	return nil
+}
+
+func newHandler() *tp.Handler {
+	h := tp.NewHandler(tp.NewFamily("tptest", []string{"1.0"}, []string{myns.Prefix()}))
+
+	h.Add(1, tp.MakeHandlerFunc(func(ctx *tp.Context, e *entry) *processor.InvalidTransactionError {
+		addr := myns.MakeAddress(e.Key)
+		if ok, err := ctx.Get(addr, nil); err != nil {
+			return err
+		} else if ok {
+			return tp.Conflict.TxErrorf("key exists: %s", e.Key)
+		}
+
+		if err := ctx.Set(addr, &e.Setting_Entry); err != nil {
+			return err
+		}
+
+		if err := ctx.AddReceiptData(&e.Setting_Entry); err != nil {
+			return err
+		}
+
+		return ctx.AddEventMessage("tptest/set", &e.Setting_Entry, tp.Attribute("key", e.Key))
+	}))
+
+	return h
+}
+
+func TestHarness(t *testing.T) {
+	hn := tptest.New(newHandler())
+	want := &setting_pb2.Setting_Entry{Key: "a", Value: "1"}
+
+	r := hn.Invoke("signer", 1, want)
+	r.AssertOK(t)
+	hn.AssertState(t, myns.MakeAddress("a"), want)
+
+	evt := r.AssertEvent(t, "tptest/set", new(setting_pb2.Setting_Entry))
+	if v, _ := evt.Attribute("key"); v != "a" {
+		t.Errorf("event attribute key want a, but %q", v)
+	}
+
+	receipt := new(setting_pb2.Setting_Entry)
+	r.AssertReceipt(t, 0, receipt)
+
+	hn.Invoke("signer", 1, want).AssertCode(t, tp.Conflict)
+	hn.Invoke("signer", 1, &setting_pb2.Setting_Entry{}).AssertCode(t, tp.BadParameters)
+	hn.Invoke("signer", 2, nil).AssertCode(t, tp.UnknownCmd)
+	hn.AssertState(t, myns.MakeAddress("b"), nil)
+}
+
+func TestHarnessDeclared(t *testing.T) {
+	hn := tptest.New(newHandler()).Inputs(myns.Prefix()).Outputs(myns.MakeAddress("a"))
+
+	hn.Invoke("signer", 1, &setting_pb2.Setting_Entry{Key: "a"}).AssertOK(t)
+
+	r := hn.Invoke("signer", 1, &setting_pb2.Setting_Entry{Key: "b"})
+	r.AssertCode(t, tp.Undeclared)
+	if !strings.Contains(r.Err.Error(), myns.MakeAddress("b")) || !strings.Contains(r.Err.Error(), "tptest ("+myns.Prefix()+")") {
+		t.Fatalf("error must name address and namespace: %v", r.Err)
+	}
+	hn.AssertState(t, myns.MakeAddress("b"), nil)
+
+	hn.Inputs(myns.MakeAddress("a"))
+	hn.Invoke("signer", 1, &setting_pb2.Setting_Entry{Key: "c"}).AssertCode(t, tp.Undeclared)
+}
+
+func TestHarnessMulti(t *testing.T) {
+	hn := tptest.New(newHandler())
+
+	req := tp.NewMultiTPRequest()
+	req.Add(1, &setting_pb2.Setting_Entry{Key: "a"})
+	req.Add(1, &setting_pb2.Setting_Entry{Key: "b"})
+	hn.InvokeRequest("signer", req).AssertOK(t)
+	hn.AssertState(t, myns.MakeAddress("b"), &setting_pb2.Setting_Entry{Key: "b"})
+
+	req = tp.NewMultiTPRequest()
+	req.Add(1, &setting_pb2.Setting_Entry{Key: "c"})
+	req.Add(1, &setting_pb2.Setting_Entry{Key: "a"})
+
+	r := hn.InvokeRequest("signer", req)
+	r.AssertCode(t, tp.Conflict)
+	hn.AssertState(t, myns.MakeAddress("c"), nil)
+
+	if e, ok := tp.AsCodeError(r.Err); !ok || e.Index != 1 {
+		t.Fatalf("failing index want 1, but %v", e)
+	}
+}
@@ -0,0 +1,193 @@
+package tx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dairaga/log"
+	"github.com/dairaga/sawtk/ns"
+	"github.com/dairaga/sawtk/util"
+	"github.com/golang/protobuf/proto"
+)
+
+// isPrefix returns s is an address or a namespace prefix.
+func isPrefix(s string) bool {
+	return len(s) > 0 && len(s) <= 70 && util.IsHexString(s)
+}
+
+// sortedKeys returns sorted keys of a set.
+func sortedKeys(set map[string]bool) []string {
+	ret := make([]string, 0, len(set))
+	for k := range set {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// covered returns true if addr is equal to or under any prefix in set.
+func covered(set map[string]bool, addr string) bool {
+	for k := range set {
+		if strings.HasPrefix(addr, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// ----------------------------------------------------------------------------
+
+// AddressSet collects inputs and outputs of a transaction.
+// Addresses are deduplicated, sorted and validated when building.
+type AddressSet struct {
+	namespaces      []ns.Namespace
+	readBeforeWrite bool
+	inputs          map[string]bool
+	outputs         map[string]bool
+	errs            []string
+	warnings        []string
+}
+
+// NewAddressSet returns an address set.
+// If namespaces are given, all addresses must be under one of them.
+func NewAddressSet(namespaces ...ns.Namespace) *AddressSet {
+	return &AddressSet{
+		namespaces: namespaces,
+		inputs:     make(map[string]bool),
+		outputs:    make(map[string]bool),
+	}
+}
+
+func (s *AddressSet) String() string {
+	in := strings.Join(sortedKeys(s.inputs), `","`)
+	out := strings.Join(sortedKeys(s.outputs), `","`)
+	return fmt.Sprintf(`{"inputs": ["%s"], "outputs": ["%s"]}`, in, out)
+}
+
+// ReadBeforeWrite sets the family reads every address before writing it.
+// Outputs not in inputs will be warned when building.
+func (s *AddressSet) ReadBeforeWrite(flag bool) *AddressSet {
+	s.readBeforeWrite = flag
+	return s
+}
+
+// allowed returns addr is under the namespaces of set.
+func (s *AddressSet) allowed(addr string) bool {
+	if len(s.namespaces) <= 0 {
+		return true
+	}
+
+	for _, n := range s.namespaces {
+		if strings.HasPrefix(addr, n.Prefix()) {
+			return true
+		}
+	}
+	return false
+}
+
+// add validates addresses and puts them into set.
+func (s *AddressSet) add(set map[string]bool, addrs ...string) {
+	for _, x := range addrs {
+		x = strings.ToLower(strings.TrimSpace(x))
+		if !isPrefix(x) {
+			s.errs = append(s.errs, fmt.Sprintf("invalid address or prefix: %q", x))
+			continue
+		}
+
+		if !s.allowed(x) {
+			s.errs = append(s.errs, fmt.Sprintf("address %s is not in namespaces", x))
+			continue
+		}
+
+		set[x] = true
+	}
+}
+
+// Input adds addresses or namespace prefixes into inputs.
+func (s *AddressSet) Input(addrs ...string) *AddressSet {
+	s.add(s.inputs, addrs...)
+	return s
+}
+
+// Output adds addresses or namespace prefixes into outputs.
+func (s *AddressSet) Output(addrs ...string) *AddressSet {
+	s.add(s.outputs, addrs...)
+	return s
+}
+
+// InOut adds addresses or namespace prefixes into both inputs and outputs.
+func (s *AddressSet) InOut(addrs ...string) *AddressSet {
+	return s.Input(addrs...).Output(addrs...)
+}
+
+// InputKey adds address made from namespace and key into inputs.
+func (s *AddressSet) InputKey(n ns.Namespace, key string) *AddressSet {
+	return s.Input(n.MakeAddress(key))
+}
+
+// OutputKey adds address made from namespace and key into outputs.
+func (s *AddressSet) OutputKey(n ns.Namespace, key string) *AddressSet {
+	return s.Output(n.MakeAddress(key))
+}
+
+// InOutKey adds address made from namespace and key into both inputs and outputs.
+func (s *AddressSet) InOutKey(n ns.Namespace, key string) *AddressSet {
+	return s.InOut(n.MakeAddress(key))
+}
+
+// Setting adds address of a setting key (ex: sawtooth.config.vote.proposals) into inputs.
+// Settings are always read only to other families.
+func (s *AddressSet) Setting(key string) *AddressSet {
+	s.inputs[ns.Settings().MakeAddress(key)] = true
+	return s
+}
+
+// Warnings returns warnings found in last building.
+func (s *AddressSet) Warnings() []string {
+	return s.warnings
+}
+
+// Build returns sorted inputs and outputs.
+func (s *AddressSet) Build() (in, out []string, err error) {
+	if len(s.errs) > 0 {
+		return nil, nil, fmt.Errorf("address set: %s", strings.Join(s.errs, "; "))
+	}
+
+	s.warnings = nil
+	out = sortedKeys(s.outputs)
+
+	if s.readBeforeWrite {
+		for _, x := range out {
+			if !covered(s.inputs, x) {
+				msg := fmt.Sprintf("output %s is not in inputs", x)
+				log.Warn(msg)
+				s.warnings = append(s.warnings, msg)
+			}
+		}
+	}
+
+	return sortedKeys(s.inputs), out, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// NewWithAddressSet returns Data with inputs and outputs in address set.
+func NewWithAddressSet(family, version string, pb proto.Message, set *AddressSet) (*Data, error) {
+	in, out, err := set.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(family, version, pb, in, out)
+}
+
+// NewRawWithAddressSet returns Data with encoded payload, and inputs and outputs in address set.
+func NewRawWithAddressSet(family, version string, payload []byte, set *AddressSet) (*Data, error) {
+	in, out, err := set.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRaw(family, version, payload, in, out), nil
+}
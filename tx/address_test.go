@@ -0,0 +1,56 @@
+package tx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dairaga/sawtk/ns"
+)
+
+func TestAddressSet(t *testing.T) {
+	myns := ns.New("intkey")
+	a := myns.MakeAddress("a")
+	b := myns.MakeAddress("b")
+
+	in, out, err := NewAddressSet(myns).
+		ReadBeforeWrite(true).
+		Input(b, a, a).
+		Output(a).
+		Setting("sawtooth.config.vote.proposals").
+		Build()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(in) != 3 || in[0] != "000000a87cb5eafdcca6a8b79606fb3afea5bdab274474a6aa82c1c0cbf0fbcaf64c0b" {
+		t.Errorf("inputs must be sorted and deduplicated, but %v", in)
+	}
+
+	if len(out) != 1 || out[0] != a {
+		t.Errorf("outputs want [%s], but %v", a, out)
+	}
+}
+
+func TestAddressSetInvalid(t *testing.T) {
+	myns := ns.New("intkey")
+
+	_, _, err := NewAddressSet(myns).Input("xyz", ns.New("other").MakeAddress("a")).Build()
+	if err == nil {
+		t.Fatal("invalid address and address not in namespaces must fail")
+	}
+
+	set := NewAddressSet(myns).ReadBeforeWrite(true).Input(myns.Prefix()).Output(myns.MakeAddress("a"), strings.Repeat("1", 6))
+	if _, _, err := set.Build(); err == nil {
+		t.Fatal("address not in namespaces must fail")
+	}
+
+	set = NewAddressSet().ReadBeforeWrite(true).Input(myns.Prefix()).Output(myns.MakeAddress("a"), ns.New("other").Prefix())
+	if _, _, err := set.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(set.Warnings()) != 1 {
+		t.Errorf("output not in inputs must be warned, but %v", set.Warnings())
+	}
+}
@@ -20,6 +20,11 @@ func NewBatchBuilder(signer *signing.Signer) *BatchBuilder {
 	return &BatchBuilder{signer: signer}
 }
 
+// Signer returns the batch signer.
+func (b *BatchBuilder) Signer() *signing.Signer {
+	return b.signer
+}
+
 func (b *BatchBuilder) String() string {
 	return fmt.Sprintf("signer: %s", b.signer.GetPublicKey().AsHex())
 }
@@ -84,6 +89,11 @@ func NewBuilder(batchSignerPublicKey string, signer *signing.Signer) *Builder {
 	return &Builder{batchSignerPublicKey: batchSignerPublicKey, signer: signer}
 }
 
+// Signer returns the transaction signer.
+func (b *Builder) Signer() *signing.Signer {
+	return b.signer
+}
+
 func (b *Builder) String() string {
 	return fmt.Sprintf("batch signer: %s, signer: %s", b.batchSignerPublicKey, b.signer.GetPublicKey().AsHex())
 }
@@ -0,0 +1,193 @@
+package tx
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/dairaga/log"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/batch_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+// parallel calls f with 0 to n-1 in workers goroutines and returns the first error.
+func parallel(n, workers int, f func(int) error) error {
+	if workers > n {
+		workers = n
+	}
+
+	jobs := make(chan int)
+	errs := make(chan error, workers)
+	wg := new(sync.WaitGroup)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := f(i); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	var err error
+loop:
+	for i := 0; i < n; i++ {
+		select {
+		case err = <-errs:
+			break loop
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err == nil && len(errs) > 0 {
+		err = <-errs
+	}
+	return err
+}
+
+// ----------------------------------------------------------------------------
+
+// BulkStats is statistics of bulk building.
+type BulkStats struct {
+	Transactions int
+	Batches      int
+	Elapsed      time.Duration
+}
+
+// TxPerSecond returns throughput of transactions.
+func (s *BulkStats) TxPerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Transactions) / s.Elapsed.Seconds()
+}
+
+func (s *BulkStats) String() string {
+	return fmt.Sprintf(`{"transactions": %d, "batches": %d, "elapsed": "%v", "tps": %.2f}`, s.Transactions, s.Batches, s.Elapsed, s.TxPerSecond())
+}
+
+// ----------------------------------------------------------------------------
+
+// BulkBuilder signs transactions and batches concurrently.
+// Orders of output transactions and batches are the same as input data.
+type BulkBuilder struct {
+	txb       *Builder
+	bb        *BatchBuilder
+	workers   int
+	batchSize int
+}
+
+// NewBulkBuilder returns a bulk builder.
+// workers is number of signing goroutines, and it is number of CPUs if workers <= 0.
+func NewBulkBuilder(txb *Builder, bb *BatchBuilder, workers int) *BulkBuilder {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return &BulkBuilder{
+		txb:       txb,
+		bb:        bb,
+		workers:   workers,
+		batchSize: 100,
+	}
+}
+
+func (b *BulkBuilder) String() string {
+	return fmt.Sprintf("workers: %d, batch size: %d, %s", b.workers, b.batchSize, b.txb.String())
+}
+
+// BatchSize sets max number of transactions in a batch. Default is 100.
+func (b *BulkBuilder) BatchSize(size int) *BulkBuilder {
+	if size <= 0 {
+		panic("batch size must be positive")
+	}
+	b.batchSize = size
+	return b
+}
+
+// Build returns signed transactions in the same order of data.
+func (b *BulkBuilder) Build(data ...*Data) ([]*transaction_pb2.Transaction, error) {
+	txs := make([]*transaction_pb2.Transaction, len(data))
+
+	err := parallel(len(data), b.workers, func(i int) (err error) {
+		txs[i], err = b.txb.Build(data[i])
+		if err != nil {
+			return fmt.Errorf("transaction %d: %v", i, err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// BuildBatches returns signed batches including all data in order, and statistics.
+func (b *BulkBuilder) BuildBatches(data ...*Data) ([]*batch_pb2.Batch, *BulkStats, error) {
+	start := time.Now()
+
+	txs, err := b.Build(data...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	size := (len(txs) + b.batchSize - 1) / b.batchSize
+	batches := make([]*batch_pb2.Batch, size)
+
+	err = parallel(size, b.workers, func(i int) (err error) {
+		end := (i + 1) * b.batchSize
+		if end > len(txs) {
+			end = len(txs)
+		}
+
+		batches[i], err = b.bb.Build(txs[i*b.batchSize : end]...)
+		if err != nil {
+			return fmt.Errorf("batch %d: %v", i, err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stats := &BulkStats{
+		Transactions: len(txs),
+		Batches:      size,
+		Elapsed:      time.Since(start),
+	}
+	log.Infof("bulk build: %v", stats)
+
+	return batches, stats, nil
+}
+
+// BuildLists returns batch lists and each list has at most size batches.
+func (b *BulkBuilder) BuildLists(size int, data ...*Data) ([]*batch_pb2.BatchList, *BulkStats, error) {
+	if size <= 0 {
+		panic("size of batch list must be positive")
+	}
+
+	batches, stats, err := b.BuildBatches(data...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var lists []*batch_pb2.BatchList
+	for i := 0; i < len(batches); i += size {
+		end := i + size
+		if end > len(batches) {
+			end = len(batches)
+		}
+		lists = append(lists, BatchList(batches[i:end]...))
+	}
+
+	return lists, stats, nil
+}
@@ -0,0 +1,52 @@
+package tx
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/dairaga/sawtk/signing"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+func TestBulkBuilder(t *testing.T) {
+	signer := signing.GenerateSignerFromCode("bulk")
+	b := NewBulkBuilder(NewBuilder(signer.GetPublicKey().AsHex(), signer), NewBatchBuilder(signer), 4).BatchSize(7)
+
+	data := make([]*Data, 50)
+	for i := range data {
+		d, err := New("bulk", "1.0", &transaction_pb2.TransactionHeader{Nonce: fmt.Sprint(i)}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data[i] = d
+	}
+
+	batches, stats, err := b.BuildBatches(data...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Transactions != 50 || stats.Batches != 8 || len(batches) != 8 {
+		t.Fatalf("stats: %v, batches: %d", stats, len(batches))
+	}
+
+	i := 0
+	for _, batch := range batches {
+		for _, x := range batch.Transactions {
+			if !bytes.Equal(x.Payload, data[i].Payload()) {
+				t.Fatalf("transaction %d out of order", i)
+			}
+			i++
+		}
+	}
+
+	lists, _, err := b.BuildLists(3, data...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lists) != 3 || len(lists[2].Batches) != 2 {
+		t.Errorf("batch lists: %d", len(lists))
+	}
+}
@@ -0,0 +1,194 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/dairaga/sawtk/signing"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/batch_pb2"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+// UnsignedTx is a transaction whose header is waiting for signing.
+// It can be exported to a portable JSON file, signed on another device,
+// and imported back to be batched by a service with its own BatchBuilder.
+type UnsignedTx struct {
+	Header          []byte `json:"header"`
+	Payload         []byte `json:"payload"`
+	HeaderSignature string `json:"header_signature,omitempty"`
+}
+
+// Unsigned returns an unsigned transaction.
+// txkey is public key of transaction signer, and batchkey is public key of batch signer.
+func (d *Data) Unsigned(txkey, batchkey string, dependencies ...string) (*UnsignedTx, error) {
+	header := d.TxHeader(txkey, batchkey, dependencies...)
+	headerBytes, err := proto.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UnsignedTx{
+		Header:  headerBytes,
+		Payload: d.payload,
+	}, nil
+}
+
+// ImportUnsignedTx returns an unsigned transaction from reader.
+func ImportUnsignedTx(r io.Reader) (*UnsignedTx, error) {
+	u := new(UnsignedTx)
+	if err := json.NewDecoder(r).Decode(u); err != nil {
+		return nil, err
+	}
+
+	if _, err := u.TxHeader(); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// LoadUnsignedTx returns an unsigned transaction from file.
+func LoadUnsignedTx(file string) (*UnsignedTx, error) {
+	dataBytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	u := new(UnsignedTx)
+	if err := json.Unmarshal(dataBytes, u); err != nil {
+		return nil, err
+	}
+
+	if _, err := u.TxHeader(); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (u *UnsignedTx) String() string {
+	tmp, err := json.Marshal(u)
+	if err != nil {
+		return err.Error()
+	}
+	return string(tmp)
+}
+
+// Export writes unsigned transaction into writer in JSON.
+func (u *UnsignedTx) Export(w io.Writer) error {
+	return json.NewEncoder(w).Encode(u)
+}
+
+// Save writes unsigned transaction into file in JSON.
+func (u *UnsignedTx) Save(file string) error {
+	dataBytes, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, dataBytes, 0644)
+}
+
+// TxHeader returns the decoded transaction header.
+func (u *UnsignedTx) TxHeader() (*transaction_pb2.TransactionHeader, error) {
+	header := new(transaction_pb2.TransactionHeader)
+	if err := proto.Unmarshal(u.Header, header); err != nil {
+		return nil, err
+	}
+
+	if header.PayloadSha512 != signing.SHA512(u.Payload) {
+		return nil, errors.New("payload sha512 not match")
+	}
+
+	return header, nil
+}
+
+// Signed returns transaction has a signature or not.
+func (u *UnsignedTx) Signed() bool {
+	return u.HeaderSignature != ""
+}
+
+// Sign signs header with signer.
+// The signer must be the transaction signer in header.
+func (u *UnsignedTx) Sign(signer *signing.Signer) error {
+	header, err := u.TxHeader()
+	if err != nil {
+		return err
+	}
+
+	if pubkey := signer.GetPublicKey().AsHex(); pubkey != header.SignerPublicKey {
+		return fmt.Errorf("signer %s is not transaction signer %s", pubkey, header.SignerPublicKey)
+	}
+
+	u.HeaderSignature = hex.EncodeToString(signer.Sign(u.Header))
+	return nil
+}
+
+// SetSignature imports a hex signature signed by other device.
+func (u *UnsignedTx) SetSignature(signature string) error {
+	header, err := u.TxHeader()
+	if err != nil {
+		return err
+	}
+
+	ok, err := signing.VerifyHex(signature, hex.EncodeToString(u.Header), header.SignerPublicKey)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("signature is not signed by %s", header.SignerPublicKey)
+	}
+
+	u.HeaderSignature = signature
+	return nil
+}
+
+// Transaction returns a signed sawtooth transaction.
+func (u *UnsignedTx) Transaction() (*transaction_pb2.Transaction, error) {
+	if !u.Signed() {
+		return nil, errors.New("transaction is not signed")
+	}
+
+	if err := u.SetSignature(u.HeaderSignature); err != nil {
+		return nil, err
+	}
+
+	return &transaction_pb2.Transaction{
+		Header:          u.Header,
+		HeaderSignature: u.HeaderSignature,
+		Payload:         u.Payload,
+	}, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// BuildSigned returns a sawtooth batch with transactions signed offline.
+// Batcher public key in each transaction header must be the batch signer.
+func (b *BatchBuilder) BuildSigned(us ...*UnsignedTx) (*batch_pb2.Batch, error) {
+	batchkey := b.signer.GetPublicKey().AsHex()
+	txs := make([]*transaction_pb2.Transaction, len(us))
+
+	for i, u := range us {
+		header, err := u.TxHeader()
+		if err != nil {
+			return nil, err
+		}
+
+		if header.BatcherPublicKey != batchkey {
+			return nil, fmt.Errorf("transaction %d: batcher %s is not %s", i, header.BatcherPublicKey, batchkey)
+		}
+
+		txs[i], err = u.Transaction()
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+	}
+
+	return b.Build(txs...)
+}
@@ -0,0 +1,64 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/dairaga/sawtk/signing"
+	"github.com/hyperledger/sawtooth-sdk-go/protobuf/transaction_pb2"
+)
+
+func TestOfflineSigning(t *testing.T) {
+	user := signing.GenerateSignerFromCode("user")
+	relayer := signing.GenerateSignerFromCode("relayer")
+
+	data, err := New("intkey", "1.0", &transaction_pb2.TransactionHeader{Nonce: "test"}, []string{"1cf126"}, []string{"1cf126"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := data.Unsigned(user.GetPublicKey().AsHex(), relayer.GetPublicKey().AsHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := u.Export(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	device, err := ImportUnsignedTx(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := device.Sign(relayer); err == nil {
+		t.Fatal("only transaction signer can sign")
+	}
+
+	if err := u.SetSignature(hex.EncodeToString(relayer.Sign(u.Header))); err == nil {
+		t.Fatal("signature from other signer must fail")
+	}
+
+	if err := device.Sign(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := u.SetSignature(device.HeaderSignature); err != nil {
+		t.Fatal(err)
+	}
+
+	batch, err := NewBatchBuilder(relayer).BuildSigned(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batch.Transactions) != 1 || batch.Transactions[0].HeaderSignature != device.HeaderSignature {
+		t.Error("batch must include the signed transaction")
+	}
+
+	if _, err := NewBatchBuilder(user).BuildSigned(u); err == nil {
+		t.Error("batch signer must be batcher in transaction header")
+	}
+}
@@ -37,13 +37,18 @@ func New(family, version string, pb proto.Message, in, out []string) (*Data, err
 		return nil, err
 	}
 
+	return NewRaw(family, version, payload, in, out), nil
+}
+
+// NewRaw returns Data with encoded payload, ex: payload in JSON or CBOR.
+func NewRaw(family, version string, payload []byte, in, out []string) *Data {
 	return &Data{
 		family:  family,
 		version: version,
 		payload: payload,
 		inputs:  in,
 		outputs: out,
-	}, nil
+	}
 }
 
 // ----------------------------------------------------------------------------